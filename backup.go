@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Rc-file backups
+// ─────────────────────────────────────────────────────────────
+
+const (
+	backupTimeLayout = "20060102-150405.000"
+	// maxBackupsPerFile is how many backups of a single rc file are kept;
+	// older ones are pruned whenever a new backup is taken.
+	maxBackupsPerFile = 20
+)
+
+// backupNamePattern matches "<escaped path>.<timestamp>.bak".
+var backupNamePattern = regexp.MustCompile(`^(.+)\.(\d{8}-\d{6}\.\d{3})\.bak$`)
+
+// Backup is a saved copy of an rc file taken before promptly rewrote it.
+type Backup struct {
+	// ID is the backup's file name inside the backup directory.
+	ID string
+	// Original is the rc file the backup was taken from.
+	Original string
+	Time     time.Time
+	Path     string
+}
+
+func backupDir() (string, error) {
+	paths, err := resolvePaths()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(paths.Config, "backups"), nil
+}
+
+// backupRCFile copies rcPath into the backup directory under a timestamped
+// name and returns the backup's path. Nothing is saved, and "" is returned,
+// if rcPath doesn't exist yet.
+func backupRCFile(rcPath string) (string, error) {
+	content, err := os.ReadFile(rcPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := backupDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	key, err := backupKey(rcPath)
+	if err != nil {
+		return "", err
+	}
+	path, err := writeBackup(dir, key, content)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", rcPath, err)
+	}
+
+	if err := pruneBackups(rcPath); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// writeBackup saves content under a new timestamped name. Two backups of the
+// same file taken within a millisecond get successive timestamps rather than
+// one overwriting the other.
+func writeBackup(dir, key string, content []byte) (string, error) {
+	stamp := time.Now()
+	for {
+		path := filepath.Join(dir, fmt.Sprintf("%s.%s.bak", key, stamp.Format(backupTimeLayout)))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			stamp = stamp.Add(time.Millisecond)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(content)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		return path, nil
+	}
+}
+
+// backupKey encodes rcPath into a single file name component. Paths inside
+// the home directory are stored relative to it.
+func backupKey(rcPath string) (string, error) {
+	homeDir, err := targetHomeDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(rcPath)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(homeDir, abs); err == nil && !isOutside(rel) {
+		abs = rel
+	}
+	key := url.PathEscape(filepath.ToSlash(abs))
+	// Keep backups of dotfiles visible in directory listings.
+	if strings.HasPrefix(key, ".") {
+		key = "%2E" + key[1:]
+	}
+	return key, nil
+}
+
+// listBackups returns every backup, newest first.
+func listBackups() ([]Backup, error) {
+	dir, err := backupDir()
+	if err != nil {
+		return nil, err
+	}
+	homeDir, err := targetHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		m := backupNamePattern.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		original, err := url.PathUnescape(m[1])
+		if err != nil {
+			continue
+		}
+		original = filepath.FromSlash(original)
+		if !filepath.IsAbs(original) {
+			original = filepath.Join(homeDir, original)
+		}
+		t, err := time.ParseInLocation(backupTimeLayout, m[2], time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{
+			ID:       e.Name(),
+			Original: original,
+			Time:     t,
+			Path:     filepath.Join(dir, e.Name()),
+		})
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+	return backups, nil
+}
+
+// pruneBackups removes all but the newest maxBackupsPerFile backups of rcPath.
+func pruneBackups(rcPath string) error {
+	backups, err := listBackups()
+	if err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(rcPath)
+	if err != nil {
+		return err
+	}
+
+	kept := 0
+	for _, b := range backups {
+		if b.Original != abs {
+			continue
+		}
+		kept++
+		if kept <= maxBackupsPerFile {
+			continue
+		}
+		if err := os.Remove(b.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to prune backup %s: %w", b.ID, err)
+		}
+	}
+	return nil
+}
+
+// restoreBackup puts a backup back in place of the rc file it was taken
+// from. The current rc file is backed up first so the restore can be undone.
+func restoreBackup(b Backup) error {
+	content, err := os.ReadFile(b.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read backup %s: %w", b.ID, err)
+	}
+
+	if _, err := backupRCFile(b.Original); err != nil {
+		return fmt.Errorf("failed to back up current %s: %w", b.Original, err)
+	}
+
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(b.Original); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(b.Original), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(b.Original, content, perm)
+}
+
+// findBackup looks a backup up by ID.
+func findBackup(backups []Backup, id string) (Backup, error) {
+	for _, b := range backups {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return Backup{}, fmt.Errorf("backup %q not found (run 'promptly restore --list')", id)
+}
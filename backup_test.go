@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupRCFile(t *testing.T) {
+	paths := useHome(t)
+	rc := filepath.Join(paths.Home, ".zshrc")
+
+	path, err := backupRCFile(rc)
+	if err != nil || path != "" {
+		t.Fatalf("backupRCFile of a missing file = %q, %v; want no backup", path, err)
+	}
+
+	if err := os.WriteFile(rc, []byte("export A=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path, err = backupRCFile(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, path); got != "export A=1\n" {
+		t.Errorf("backup holds %q", got)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("backup mode = %v, want 0600", perm)
+	}
+
+	// A second backup in the same millisecond mustn't overwrite the first.
+	if err := os.WriteFile(rc, []byte("export A=2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backupRCFile(rc); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := listBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want 2", len(backups))
+	}
+	if backups[0].Original != rc || readString(t, backups[0].Path) != "export A=2\n" {
+		t.Errorf("newest backup = %+v, want the second copy of %s", backups[0], rc)
+	}
+	if readString(t, backups[1].Path) != "export A=1\n" {
+		t.Errorf("oldest backup holds %q", readString(t, backups[1].Path))
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	paths := useHome(t)
+	zshrc := filepath.Join(paths.Home, ".zshrc")
+	bashrc := filepath.Join(paths.Home, ".bashrc")
+	dir, err := backupDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// Older backups of .zshrc than the limit allows, plus one of .bashrc.
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)
+	fake := func(rc string, at time.Time) {
+		t.Helper()
+		key, err := backupKey(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := fmt.Sprintf("%s.%s.bak", key, at.Format(backupTimeLayout))
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(at.String()), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < maxBackupsPerFile+5; i++ {
+		fake(zshrc, old.Add(time.Duration(i)*time.Hour))
+	}
+	fake(bashrc, old)
+
+	if err := os.WriteFile(zshrc, []byte("export A=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	newest, err := backupRCFile(zshrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := listBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var zsh, bash []Backup
+	for _, b := range backups {
+		switch b.Original {
+		case zshrc:
+			zsh = append(zsh, b)
+		case bashrc:
+			bash = append(bash, b)
+		}
+	}
+	if len(zsh) != maxBackupsPerFile {
+		t.Fatalf("kept %d backups of .zshrc, want %d", len(zsh), maxBackupsPerFile)
+	}
+	if zsh[0].Path != newest {
+		t.Errorf("newest backup %s was pruned", newest)
+	}
+	// The new backup plus the 19 newest fakes survive.
+	wantOldest := old.Add(time.Duration(maxBackupsPerFile+5-(maxBackupsPerFile-1)) * time.Hour)
+	if got := zsh[len(zsh)-1].Time; !got.Equal(wantOldest) {
+		t.Errorf("oldest kept backup is from %v, want %v", got, wantOldest)
+	}
+	if len(bash) != 1 {
+		t.Errorf("pruning .zshrc left %d backups of .bashrc, want 1", len(bash))
+	}
+}
+
+func TestRestoreRoundTrip(t *testing.T) {
+	paths := useHome(t)
+	rc := filepath.Join(paths.Home, ".bashrc")
+	original := "# my bashrc\nalias ll='ls -l'\n"
+	if err := os.WriteFile(rc, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+	path, err := backupRCFile(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(rc, []byte("# rewritten\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := runRestore([]string{filepath.Base(path), "--home", paths.Home}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readString(t, rc); got != original {
+		t.Errorf("restored %q, want %q", got, original)
+	}
+	if info, err := os.Stat(rc); err != nil {
+		t.Fatal(err)
+	} else if perm := info.Mode().Perm(); perm != 0640 {
+		t.Errorf("restore changed the mode to %v", perm)
+	}
+
+	// The rewritten file was backed up so the restore can be undone.
+	backups, err := listBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want 2", len(backups))
+	}
+	if got := readString(t, backups[0].Path); got != "# rewritten\n" {
+		t.Errorf("newest backup holds %q, want the file restore replaced", got)
+	}
+}
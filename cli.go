@@ -0,0 +1,773 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Command line interface
+// ─────────────────────────────────────────────────────────────
+
+const usage = `Usage: promptly [command] [flags]
+
+Run without a command to start the interactive installer.
+
+Installer flags:
+  --dry-run                          Show the changes as a diff without making them
+  --palette <name>                   Recolor the theme with a palette instead of asking
+  --colors <depth>                   Render colors for auto, truecolor, 256 or 16 color
+                                     terminals (default: detected)
+  --no-icons                         Replace Nerd Font glyphs with plain text
+  --home <dir>                       Install into <dir> instead of your home directory
+                                     (also $PROMPTLY_HOME)
+
+Commands:
+  install <theme> [--shell <target>] Install a theme without prompts (--dry-run to preview)
+  list [--json]                      List available themes
+  uninstall [--dry-run]              Remove promptly from your shell config
+  restore [--list] [backup]          Put back an rc file saved before an install
+  theme rename|copy|delete <theme>   Rename, copy or delete a custom theme
+  edit <theme> [--shell <target>]    Open a custom theme in $VISUAL/$EDITOR and check it
+  lint [theme...]                    Check themes for mistakes
+  init <zsh|bash|fish> [--theme <t>] Print the hook that draws the prompt with promptly
+  prompt --shell <zsh|bash|fish>     Draw the prompt (run by the hook from init)
+  paths                              Show which files promptly reads and writes
+  help                               Show this help
+
+Run 'promptly <command> -h' for the flags of a command.
+`
+
+func runCommand(args []string) error {
+	switch args[0] {
+	case "install":
+		return runInstall(args[1:])
+	case "list":
+		return runList(args[1:])
+	case "uninstall":
+		return runUninstall(args[1:])
+	case "restore":
+		return runRestore(args[1:])
+	case "theme":
+		return runTheme(args[1:])
+	case "edit":
+		return runEdit(args[1:])
+	case "lint":
+		return runLint(args[1:])
+	case "init":
+		return runInit(args[1:])
+	case "prompt":
+		return runPrompt(args[1:])
+	case "paths":
+		return runPaths(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	}
+	return fmt.Errorf("unknown command %q (run 'promptly help' for usage)", args[0])
+}
+
+// parseInteractiveFlags parses the flags accepted by the interactive
+// installer, which runs when promptly is started without a command.
+func parseInteractiveFlags(args []string) (InstallOptions, error) {
+	fs := flag.NewFlagSet("promptly", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), usage) }
+	dryRun := fs.Bool("dry-run", false, "show the changes as a diff without making them")
+	palette := fs.String("palette", "", "recolor the theme with this palette instead of asking")
+	addColorsFlag(fs)
+	addNoIconsFlag(fs)
+	addHomeFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return InstallOptions{}, err
+	}
+	if len(positional) != 0 {
+		return InstallOptions{}, fmt.Errorf("unexpected argument %q (run 'promptly help' for usage)", positional[0])
+	}
+	return InstallOptions{DryRun: *dryRun, Palette: *palette}, nil
+}
+
+// addHomeFlag registers --home, which every command that reads or writes
+// the home directory accepts.
+func addHomeFlag(fs *flag.FlagSet) {
+	fs.StringVar(&homeOverride, "home", "", "install into this directory instead of your home directory (also $PROMPTLY_HOME)")
+}
+
+// parseArgs parses flags that may appear before, between or after positional
+// arguments and returns the positional arguments in order.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseShellTarget maps a --shell value onto a ShellTarget.
+func parseShellTarget(s string) (ShellTarget, error) {
+	switch target := ShellTarget(s); target {
+	case ShellZsh, ShellBash, ShellFish, ShellPwsh, ShellNu, ShellStarship:
+		return target, nil
+	}
+	return "", fmt.Errorf("unknown shell %q (expected zsh, bash, fish, pwsh, nu or starship)", s)
+}
+
+// findTheme looks a theme up by name, ignoring the selector's menu entries.
+func findTheme(themes []Theme, name string) (Theme, error) {
+	for _, t := range themes {
+		if t.Name == name && !isMenuEntry(t) {
+			return t, nil
+		}
+	}
+	return Theme{}, fmt.Errorf("theme %q not found", name)
+}
+
+func runInstall(args []string) error {
+	fs := flag.NewFlagSet("install", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptly install <theme> [--shell <zsh|bash|fish|pwsh|nu|starship>] [--starship-shell <zsh|bash|fish|pwsh|nu>] [--palette <name>] [--colors <depth>] [--no-icons] [--native] [--dry-run] [--home <dir>]")
+		fs.PrintDefaults()
+	}
+	shellFlag := fs.String("shell", "", "target to install for: zsh, bash, fish, pwsh, nu or starship (default: the detected shell)")
+	starshipShell := fs.String("starship-shell", "", "shell starship runs on top of: "+strings.Join(starshipShells, ", ")+" (starship only, default: the detected shell)")
+	palette := fs.String("palette", "", "recolor the theme with this palette (default: the theme's own colors)")
+	dryRun := fs.Bool("dry-run", false, "show the changes as a diff without making them")
+	native := fs.Bool("native", false, "draw the prompt with promptly prompt instead of a compiled theme (zsh, bash and fish)")
+	addColorsFlag(fs)
+	addNoIconsFlag(fs)
+	addHomeFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return errors.New("install takes exactly one theme name")
+	}
+
+	// Detection messages go to stderr so a --dry-run diff on stdout stays
+	// clean.
+	detected := detectShell()
+	if *shellFlag == "" {
+		if detected.Shell == "" {
+			return errors.New("--shell is required: could not detect your shell")
+		}
+		*shellFlag = string(detected.Shell)
+		fmt.Fprintf(os.Stderr, "Using detected shell %s.\n", detected)
+	}
+
+	shell, err := parseShellTarget(*shellFlag)
+	if err != nil {
+		return err
+	}
+
+	opts := InstallOptions{DryRun: *dryRun, Palette: *palette, Native: *native}
+	if opts.Native && !slices.Contains(nativeShells, shell) {
+		return errors.New("--native only applies to zsh, bash and fish")
+	}
+	if shell == ShellStarship {
+		if *starshipShell == "" {
+			if !slices.Contains(starshipShells, string(detected.Shell)) {
+				return errors.New("--starship-shell is required: could not detect your shell")
+			}
+			*starshipShell = string(detected.Shell)
+			fmt.Fprintf(os.Stderr, "Running starship on detected shell %s.\n", detected)
+		}
+		if !slices.Contains(starshipShells, *starshipShell) {
+			return fmt.Errorf("unsupported shell for starship: %q (expected %s)", *starshipShell, strings.Join(starshipShells, ", "))
+		}
+		opts.StarshipShell = *starshipShell
+	} else if *starshipShell != "" {
+		return errors.New("--starship-shell only applies to --shell starship")
+	}
+
+	themes, err := loadThemes()
+	if err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	theme, err := findTheme(themes, positional[0])
+	if err != nil {
+		return err
+	}
+	if _, ok := theme.Contents[shell]; !ok {
+		return fmt.Errorf("theme %q has no %s variant (available: %s)", theme.Name, shell, strings.Join(themeShells(theme), ", "))
+	}
+	if opts.Native && (theme.Spec == nil || !isGenerated(theme.Contents[shell])) {
+		return fmt.Errorf("theme %q has a hand-written %s variant, which promptly can't draw itself", theme.Name, shell)
+	}
+	if opts.Palette != "" {
+		palettes, err := loadPalettes()
+		if err != nil {
+			return fmt.Errorf("failed to load palettes: %w", err)
+		}
+		palette, err := findPalette(palettes, opts.Palette)
+		if err != nil {
+			return err
+		}
+		if theme, err = theme.withPalette(palette); err != nil {
+			return err
+		}
+	}
+
+	if !opts.DryRun {
+		if shell == ShellStarship {
+			warnMissingStarship(detected)
+		}
+		warnMissingNerdFont(theme)
+	}
+
+	if err := installTheme(theme, shell, opts); err != nil {
+		return fmt.Errorf("failed to install theme: %w", err)
+	}
+
+	if !opts.DryRun {
+		printInstallSuccess(theme, shell, opts)
+	}
+	return nil
+}
+
+// themeShells returns the targets a theme has contents for, in menu order.
+func themeShells(theme Theme) []string {
+	var shells []string
+	for _, s := range allShellTargets {
+		if _, ok := theme.Contents[s]; ok {
+			shells = append(shells, string(s))
+		}
+	}
+	return shells
+}
+
+// themeListing is the --json representation of a theme.
+type themeListing struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Custom      bool     `json:"custom"`
+	SourcePath  string   `json:"source_path,omitempty"`
+	Shells      []string `json:"shells"`
+	Author      string   `json:"author,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Font        string   `json:"font,omitempty"`
+}
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptly list [--json] [--home <dir>]")
+		fs.PrintDefaults()
+	}
+	jsonOut := fs.Bool("json", false, "print the theme list as JSON")
+	addHomeFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return errors.New("list takes no arguments")
+	}
+
+	themes, err := loadThemes()
+	if err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	listings := []themeListing{}
+	for _, t := range themes {
+		if isMenuEntry(t) {
+			continue
+		}
+		listings = append(listings, themeListing{
+			Name:        t.Name,
+			Description: t.Description,
+			Custom:      t.IsCustom,
+			SourcePath:  t.SourcePath,
+			Shells:      themeShells(t),
+			Author:      t.Meta.Author,
+			Version:     t.Meta.Version,
+			Font:        t.Meta.Font,
+		})
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listings)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tSHELLS\tSOURCE\tDESCRIPTION")
+	for _, l := range listings {
+		kind, source := "built-in", "-"
+		if l.Custom {
+			kind, source = "custom", l.SourcePath
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", l.Name, kind, strings.Join(l.Shells, ","), source, l.Description)
+	}
+	return w.Flush()
+}
+
+func runUninstall(args []string) error {
+	fs := flag.NewFlagSet("uninstall", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptly uninstall [--dry-run] [--home <dir>]")
+		fs.PrintDefaults()
+	}
+	dryRun := fs.Bool("dry-run", false, "show the changes as a diff without making them")
+	addHomeFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return errors.New("uninstall takes no arguments")
+	}
+
+	if *dryRun {
+		changes, err := planTransaction(func(tx *Transaction) error {
+			_, err := uninstallFiles(tx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		printPlan(os.Stdout, changes)
+		return nil
+	}
+
+	changed, err := uninstall()
+	if err != nil {
+		return err
+	}
+
+	printUninstallResult(changed)
+	return nil
+}
+
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptly restore [--list] [--home <dir>] [backup]")
+		fs.PrintDefaults()
+	}
+	listOnly := fs.Bool("list", false, "list backups instead of restoring one")
+	addHomeFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		fs.Usage()
+		return errors.New("restore takes at most one backup")
+	}
+
+	backups, err := listBackups()
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+	if len(backups) == 0 {
+		fmt.Println("No backups found.")
+		return nil
+	}
+
+	if *listOnly {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BACKUP\tTAKEN\tFILE")
+		for _, b := range backups {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", b.ID, b.Time.Format("2006-01-02 15:04:05"), b.Original)
+		}
+		return w.Flush()
+	}
+
+	var backup Backup
+	if len(positional) == 1 {
+		backup, err = findBackup(backups, positional[0])
+	} else {
+		backup, err = selectBackup(backups)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := restoreBackup(backup); err != nil {
+		return err
+	}
+
+	color.Green("✓ Restored %s from backup taken %s", backup.Original, backup.Time.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// themeUsage lists the theme subcommands.
+const themeUsage = `Usage:
+  promptly theme rename <theme> <new name> [--dry-run] [--home <dir>]
+  promptly theme copy <theme> <new name> [--dry-run] [--home <dir>]
+  promptly theme delete <theme> [--force] [--dry-run] [--home <dir>]
+`
+
+func runTheme(args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		fmt.Print(themeUsage)
+		if len(args) == 0 {
+			return errors.New("missing theme command")
+		}
+		return nil
+	}
+
+	action := args[0]
+	wantArgs := 2
+	switch action {
+	case "rename", "copy":
+	case "delete":
+		wantArgs = 1
+	default:
+		return fmt.Errorf("unknown theme command %q (expected rename, copy or delete)", action)
+	}
+
+	fs := flag.NewFlagSet("theme "+action, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), themeUsage)
+		fs.PrintDefaults()
+	}
+	dryRun := fs.Bool("dry-run", false, "show the changes as a diff without making them")
+	var force *bool
+	if action == "delete" {
+		force = fs.Bool("force", false, "delete the theme even if it is installed")
+	}
+	addHomeFlag(fs)
+
+	positional, err := parseArgs(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(positional) != wantArgs {
+		fs.Usage()
+		if wantArgs == 1 {
+			return fmt.Errorf("theme %s takes a theme name", action)
+		}
+		return fmt.Errorf("theme %s takes a theme and a new name", action)
+	}
+
+	themes, err := loadThemes()
+	if err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+	theme, err := lookupCustomTheme(themes, positional[0])
+	if err != nil {
+		return err
+	}
+
+	if action == "delete" {
+		paths, err := resolvePaths()
+		if err != nil {
+			return err
+		}
+		installed, err := installedShells(paths, theme.Name)
+		if err != nil {
+			return err
+		}
+		if len(installed) > 0 && !*force {
+			return fmt.Errorf("%q is installed for %s; install another theme first or pass --force", theme.Name, strings.Join(installed, ", "))
+		}
+		return deleteCustomTheme(theme.Name, installed, *dryRun)
+	}
+
+	newName := positional[1]
+	if err := checkNewThemeName(newName, themes); err != nil {
+		return fmt.Errorf("invalid name %q: %w", newName, err)
+	}
+	if action == "rename" {
+		return renameCustomTheme(theme.Name, newName, *dryRun)
+	}
+	return copyCustomTheme(theme.Name, newName, *dryRun)
+}
+
+func runEdit(args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptly edit <theme> [--shell <zsh|bash|fish|pwsh|nu|starship>] [--home <dir>]")
+		fs.PrintDefaults()
+	}
+	shellFlag := fs.String("shell", "", "edit the theme's file for this shell instead of its spec")
+	addHomeFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return errors.New("edit takes exactly one theme name")
+	}
+
+	paths, err := resolvePaths()
+	if err != nil {
+		return err
+	}
+	themes, err := loadThemes()
+	if err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+	theme, err := lookupCustomTheme(themes, positional[0])
+	if err != nil {
+		return err
+	}
+
+	path := theme.SourcePath
+	if *shellFlag != "" {
+		shell, err := parseShellTarget(*shellFlag)
+		if err != nil {
+			return err
+		}
+		content, ok := theme.Contents[shell]
+		if !ok {
+			return fmt.Errorf("theme %q has no %s variant", theme.Name, shell)
+		}
+		if isGenerated(content) {
+			return fmt.Errorf("the %s variant of %q is compiled from its spec; edit the spec instead (promptly edit %s)", shell, theme.Name, theme.Name)
+		}
+		path = filepath.Join(paths.Config, theme.Name+themeSuffixes[shell])
+	}
+
+	changed, err := editThemeFile(path)
+	if err != nil || !changed || !strings.HasSuffix(path, specSuffix) {
+		return err
+	}
+
+	// The shells source the compiled variants, so they have to follow the
+	// spec.
+	themes, err = loadThemes()
+	if err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+	if theme, err = lookupCustomTheme(themes, theme.Name); err != nil {
+		return err
+	}
+	var written []string
+	err = runTransaction(func(tx *Transaction) error {
+		written, err = writeCompiledVariants(tx, paths, theme, "")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, path := range written {
+		fmt.Printf("Recompiled %s.\n", displayPath(paths.Home, path))
+	}
+	return nil
+}
+
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptly lint [theme...] [--home <dir>]")
+		fs.PrintDefaults()
+	}
+	addHomeFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	paths, err := resolvePaths()
+	if err != nil {
+		return err
+	}
+	themes, err := loadThemes()
+	if err != nil {
+		return fmt.Errorf("failed to load themes: %w", err)
+	}
+
+	var diags []Diagnostic
+	var linted []Theme
+	if len(positional) == 0 {
+		// Custom specs and manifests that fail to load are left out of
+		// themes, so check them on their own.
+		if diags, err = lintConfigFiles(paths.Config); err != nil {
+			return err
+		}
+		for _, t := range themes {
+			if !isMenuEntry(t) {
+				linted = append(linted, t)
+			}
+		}
+	}
+	for _, name := range positional {
+		t, err := findTheme(themes, name)
+		if err != nil {
+			return err
+		}
+		linted = append(linted, t)
+	}
+	for _, t := range linted {
+		diags = append(diags, lintTheme(t, paths.Config)...)
+	}
+
+	errs := 0
+	for _, d := range diags {
+		fmt.Println(d)
+		if d.Severity == sevError {
+			errs++
+		}
+	}
+	switch {
+	case errs > 0:
+		return fmt.Errorf("lint found %d error(s) in %d theme(s)", errs, len(linted))
+	case len(diags) > 0:
+		color.Yellow("! %d warning(s) in %d theme(s)", len(diags), len(linted))
+	default:
+		color.Green("✓ %d theme(s) checked, no problems found", len(linted))
+	}
+	return nil
+}
+
+func runInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptly init <zsh|bash|fish> [--theme <name>] [--palette <name>] [--colors <depth>] [--no-icons]")
+		fmt.Fprintln(fs.Output(), "Add eval \"$(promptly init zsh)\" to ~/.zshrc, the same to ~/.bashrc for bash,")
+		fmt.Fprintln(fs.Output(), "or promptly init fish | source to ~/.config/fish/config.fish.")
+		fs.PrintDefaults()
+	}
+	opts := addRenderFlags(fs)
+
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return errors.New("init takes exactly one shell")
+	}
+	shell, err := parseNativeShell(positional[0])
+	if err != nil {
+		return err
+	}
+
+	// Check the theme once here rather than failing at every prompt, and
+	// detect the color depth once for the terminal the shell starts in.
+	if _, err := loadRenderSpec(*opts); err != nil {
+		return err
+	}
+	colorsOverride = colorDepth()
+
+	fmt.Print(initScript(shell, *opts))
+	return nil
+}
+
+func runPrompt(args []string) error {
+	fs := flag.NewFlagSet("prompt", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptly prompt --shell <zsh|bash|fish> [--status <code>] [--theme <name>] [--palette <name>] [--colors <depth>] [--no-icons]")
+		fs.PrintDefaults()
+	}
+	shellFlag := fs.String("shell", "", "shell to draw the prompt for: zsh, bash or fish")
+	status := fs.Int("status", 0, "exit status of the last command")
+	opts := addRenderFlags(fs)
+
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return errors.New("prompt takes no arguments")
+	}
+	shell, err := parseNativeShell(*shellFlag)
+	if err != nil {
+		return err
+	}
+
+	spec, err := loadRenderSpec(*opts)
+	if err != nil {
+		return err
+	}
+	fmt.Print(renderPrompt(shell, spec, readGitInfo(""), *status))
+	return nil
+}
+
+func runPaths(args []string) error {
+	fs := flag.NewFlagSet("paths", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: promptly paths [--home <dir>]")
+		fs.PrintDefaults()
+	}
+	addHomeFlag(fs)
+
+	positional, err := parseArgs(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return errors.New("paths takes no arguments")
+	}
+
+	paths, err := resolvePaths()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, entry := range paths.Describe() {
+		label, path := entry[0], entry[1]
+		if origin, ok := paths.Origins[label]; ok {
+			fmt.Fprintf(w, "%s\t%s\t(from %s)\n", label, path, origin)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t\n", label, path)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Color depth
+// ─────────────────────────────────────────────────────────────
+
+// ColorDepth is how many colors a terminal can show.
+type ColorDepth int
+
+const (
+	DepthAuto ColorDepth = iota
+	Depth16
+	Depth256
+	DepthTrue
+)
+
+func (d ColorDepth) String() string {
+	switch d {
+	case Depth16:
+		return "16"
+	case Depth256:
+		return "256"
+	case DepthTrue:
+		return "truecolor"
+	}
+	return "auto"
+}
+
+// parseColorDepth parses a --colors value.
+func parseColorDepth(s string) (ColorDepth, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return DepthAuto, nil
+	case "16":
+		return Depth16, nil
+	case "256":
+		return Depth256, nil
+	case "truecolor", "24bit":
+		return DepthTrue, nil
+	}
+	return DepthAuto, fmt.Errorf("unknown color depth %q (expected auto, truecolor, 256 or 16)", s)
+}
+
+// colorsOverride is set by --colors. DepthAuto means detect.
+var colorsOverride ColorDepth
+
+// addColorsFlag registers --colors on the commands that render previews or
+// install themes.
+func addColorsFlag(fs *flag.FlagSet) {
+	fs.Func("colors", "color depth to render for: auto, truecolor, 256 or 16 (default: auto)", func(s string) error {
+		d, err := parseColorDepth(s)
+		colorsOverride = d
+		return err
+	})
+}
+
+// colorDepth returns the color depth previews are rendered for. A terminal
+// that can't be identified gets the 16 colors every terminal has.
+func colorDepth() ColorDepth {
+	if colorsOverride != DepthAuto {
+		return colorsOverride
+	}
+	if d := detectedColorDepth(); d != DepthAuto {
+		return d
+	}
+	return Depth16
+}
+
+// themeColorDepth returns the color depth theme files are compiled for.
+// Installs are often scripted, from a Dockerfile or a CI job whose terminal
+// says nothing about the one the prompt will be drawn in, so without
+// --colors themes are only downsampled when promptly runs in a terminal it
+// can identify. Otherwise they keep the spec's own colors.
+func themeColorDepth() ColorDepth {
+	if colorsOverride != DepthAuto {
+		return colorsOverride
+	}
+	if info, err := os.Stdout.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return DepthTrue
+	}
+	if d := detectedColorDepth(); d != DepthAuto {
+		return d
+	}
+	return DepthTrue
+}
+
+var detectedColorDepth = sync.OnceValue(detectColorDepth)
+
+// detectColorDepth works out the color depth of the terminal promptly runs
+// in from $COLORTERM, then $TERM, then the terminfo entry for $TERM. It
+// returns DepthAuto if $TERM is unset or terminfo doesn't know it.
+func detectColorDepth() ColorDepth {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return DepthTrue
+	}
+	if os.Getenv("WT_SESSION") != "" {
+		// Windows Terminal supports truecolor but doesn't say so.
+		return DepthTrue
+	}
+
+	term := os.Getenv("TERM")
+	switch {
+	case strings.HasSuffix(term, "-direct"), strings.HasSuffix(term, "-truecolor"):
+		return DepthTrue
+	case strings.HasSuffix(term, "-256color"):
+		return Depth256
+	}
+
+	switch n := terminfoColors(term); {
+	case n >= 1<<24:
+		return DepthTrue
+	case n >= 256:
+		return Depth256
+	case n > 0:
+		return Depth16
+	}
+	return DepthAuto
+}
+
+// terminfoColors returns the number of colors terminfo lists for term, or 0
+// if it can't be looked up.
+func terminfoColors(term string) int {
+	if term == "" {
+		return 0
+	}
+	out, err := exec.Command("tput", "-T", term, "colors").Output()
+	if err != nil {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// downsample returns the nearest color to c that a terminal with the given
+// depth can show. The 16 basic colors are left alone: every terminal has
+// them, in whatever shades its color scheme picks.
+func (c Color) downsample(depth ColorDepth) Color {
+	switch {
+	case depth == DepthTrue || depth == DepthAuto || c.Name != "":
+		return c
+	case !c.RGB && (depth == Depth256 || c.Index < 16):
+		return c
+	case depth == Depth256:
+		return Color{Index: nearest256(c.rgb())}
+	}
+
+	i := nearest16(c.rgb())
+	if i < len(basicColors) {
+		return Color{Name: basicColors[i]}
+	}
+	return Color{Index: i}
+}
+
+// nearest16 returns the basic color index closest to r, g, b. Terminals
+// pick their own shades for these, so it matches by hue rather than exact
+// RGB distance, which would turn most muted colors gray.
+func nearest16(r, g, b uint8) int {
+	hi := max(r, g, b)
+	lo := min(r, g, b)
+	if hi == 0 || float64(hi-lo)/float64(hi) < 0.15 {
+		switch {
+		case hi < 64:
+			return 0 // black
+		case hi < 160:
+			return 8 // bright black
+		case hi < 230:
+			return 7 // white
+		}
+		return 15 // bright white
+	}
+
+	// Hue sectors, starting at red, in basic color indexes.
+	sectors := [6]int{1, 3, 2, 6, 4, 5}
+	fr, fg, fb, delta := float64(r), float64(g), float64(b), float64(hi-lo)
+	var hue float64
+	switch hi {
+	case r:
+		hue = math.Mod((fg-fb)/delta, 6)
+	case g:
+		hue = (fb-fr)/delta + 2
+	default:
+		hue = (fr-fg)/delta + 4
+	}
+	i := sectors[int(math.Round(hue+6))%6]
+	if hi >= 230 {
+		i += 8
+	}
+	return i
+}
+
+// cubeLevels are the channel values of the 6x6x6 color cube.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// nearest256 returns the index of the 256-color palette entry nearest to
+// r, g, b, out of the color cube and the grayscale ramp. The first 16
+// entries are skipped because terminals theme them.
+func nearest256(r, g, b uint8) int {
+	level := func(v uint8) int {
+		best := 0
+		for i, l := range cubeLevels {
+			if absDiff(v, l) < absDiff(v, cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	cube := 16 + 36*level(r) + 6*level(g) + level(b)
+
+	avg := (int(r) + int(g) + int(b)) / 3
+	step := (avg - 8 + 5) / 10
+	step = max(0, min(23, step))
+	gray := 232 + step
+
+	target := Color{RGB: true, R: r, G: g, B: b}
+	if distance(Color{Index: gray}, target) < distance(Color{Index: cube}, target) {
+		return gray
+	}
+	return cube
+}
+
+// distance is the squared distance between two colors in RGB space.
+func distance(a, b Color) int {
+	ar, ag, ab := a.rgb()
+	br, bg, bb := b.rgb()
+	dr, dg, db := int(ar)-int(br), int(ag)-int(bg), int(ab)-int(bb)
+	return dr*dr + dg*dg + db*db
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
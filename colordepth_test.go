@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNearest256(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want int
+	}{
+		{"#000000", 16},
+		{"#FFFFFF", 231},
+		{"#FF0000", 196},
+		{"#5F87AF", 67},
+		{"#D47766", 173},
+		{"#808080", 244},
+		{"#1C1C1C", 234},
+	}
+	for _, tt := range tests {
+		c, err := parseColor(tt.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := nearest256(c.rgb()); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestNearest16(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want int
+	}{
+		{"#101010", 0},
+		{"#CC0000", 1},
+		{"#00AA00", 2},
+		{"#AAAA00", 3},
+		{"#0000CC", 4},
+		{"#AA00AA", 5},
+		{"#00AAAA", 6},
+		{"#C8C8C8", 7},
+		{"#808080", 8},
+		{"#FF0000", 9},
+		{"#F0F0F0", 15},
+		{"#85B695", 2},
+		// Half a sector from yellow, where rounding decides.
+		{"#C1A78E", 1},
+	}
+	for _, tt := range tests {
+		c, err := parseColor(tt.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := nearest16(c.rgb()); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestParseColorDepth(t *testing.T) {
+	tests := map[string]ColorDepth{
+		"":          DepthAuto,
+		"auto":      DepthAuto,
+		"16":        Depth16,
+		"256":       Depth256,
+		"truecolor": DepthTrue,
+		"TrueColor": DepthTrue,
+		"24bit":     DepthTrue,
+	}
+	for s, want := range tests {
+		if got, err := parseColorDepth(s); err != nil || got != want {
+			t.Errorf("%q: got %s, %v, want %s", s, got, err, want)
+		}
+	}
+	if _, err := parseColorDepth("88"); err == nil {
+		t.Errorf("88 was accepted")
+	}
+}
+
+func TestDetectColorDepth(t *testing.T) {
+	tests := []struct {
+		colorterm, wtSession, term string
+		want                       ColorDepth
+	}{
+		{"truecolor", "", "xterm-256color", DepthTrue},
+		{"24bit", "", "vt100", DepthTrue},
+		{"", "", "xterm-direct", DepthTrue},
+		{"", "", "xterm-256color", Depth256},
+		{"yes", "", "screen-256color", Depth256},
+		{"", "1", "", DepthTrue},
+		{"", "", "", DepthAuto},
+	}
+	for _, tt := range tests {
+		t.Setenv("COLORTERM", tt.colorterm)
+		t.Setenv("WT_SESSION", tt.wtSession)
+		t.Setenv("TERM", tt.term)
+		if got := detectColorDepth(); got != tt.want {
+			t.Errorf("COLORTERM=%q WT_SESSION=%q TERM=%q: got %s, want %s", tt.colorterm, tt.wtSession, tt.term, got, tt.want)
+		}
+	}
+}
+
+// TestInstallWithoutTerminalKeepsColors checks that a scripted install, with
+// no terminal to go by, writes the theme in the spec's own colors.
+func TestInstallWithoutTerminalKeepsColors(t *testing.T) {
+	paths := useHome(t)
+	t.Setenv("TERM", "")
+	t.Setenv("COLORTERM", "")
+	t.Setenv("WT_SESSION", "")
+	// Like a Dockerfile RUN step, whatever go test is run from.
+	stdout := os.Stdout
+	out, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = out
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		out.Close()
+	})
+
+	themes, err := loadThemes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	theme, err := findTheme(themes, "owly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := installTheme(theme, ShellZsh, InstallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	muted, err := parseColor(theme.Spec.Colors["muted"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, paths.ZshTheme); !strings.Contains(got, "%F{"+muted.Zsh()+"}") {
+		t.Errorf("the installed theme isn't in truecolor:\n%s", got)
+	}
+}
+
+// TestStarshipKeepsColors checks that the starship config is never
+// downsampled, even with --colors.
+func TestStarshipKeepsColors(t *testing.T) {
+	colorsOverride = Depth16
+	t.Cleanup(func() { colorsOverride = DepthAuto })
+	spec := builtinSpecs(t)["owly"]
+	contents := compileTheme("owly", spec)
+	if want := `muted = "` + spec.Colors["muted"] + `"`; !strings.Contains(contents[ShellStarship], want) {
+		t.Errorf("the starship palette has no %s:\n%s", want, contents[ShellStarship])
+	}
+	if strings.Contains(contents[ShellZsh], spec.Colors["muted"]) {
+		t.Errorf("--colors 16 didn't downsample the zsh theme")
+	}
+}
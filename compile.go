@@ -0,0 +1,920 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Theme compiler
+// ─────────────────────────────────────────────────────────────
+
+// generatedMarker appears in the header of every compiled theme file, so the
+// loader can tell a compiled copy from a hand-written variant.
+const generatedMarker = "Generated by promptly from "
+
+// compiledTargets are the shells a theme spec is compiled for.
+var compiledTargets = []ShellTarget{ShellZsh, ShellBash, ShellFish, ShellPwsh, ShellNu, ShellStarship}
+
+// compileTheme compiles a validated spec into a theme file for every shell.
+// Glyphs are kept even with --no-icons; installedContent replaces them.
+func compileTheme(name string, spec ThemeSpec) map[ShellTarget]string {
+	return map[ShellTarget]string{
+		ShellZsh:      compileZsh(name, spec),
+		ShellBash:     compileBash(name, spec),
+		ShellFish:     compileFish(name, spec),
+		ShellPwsh:     compilePwsh(name, spec),
+		ShellNu:       compileNu(name, spec),
+		ShellStarship: compileStarship(name, spec),
+	}
+}
+
+// isGenerated reports whether a theme file was compiled from a spec.
+func isGenerated(content string) bool {
+	head := content
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	return strings.Contains(head, generatedMarker)
+}
+
+func writeHeader(b *strings.Builder, comment, name, suffix string) {
+	fmt.Fprintf(b, "%s %s%s\n", comment, name, suffix)
+	fmt.Fprintf(b, "%s %s%s%s. Edit the spec, not this file.\n\n", comment, generatedMarker, name, specSuffix)
+}
+
+// gitValues maps git segment types to the name of the value each shell's
+// git info function sets.
+var gitValues = map[string]string{
+	segGitHost:   "host",
+	segBranch:    "branch",
+	segSync:      "sync",
+	segStaged:    "staged",
+	segUnstaged:  "unstaged",
+	segUntracked: "untracked",
+	segStashed:   "stashed",
+}
+
+// joinParts joins the non-empty parts of an expression with sep, dropping
+// empty string literals.
+func joinParts(sep string, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		switch part {
+		case "", "''", `""`:
+			continue
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(kept, sep)
+}
+
+// ─────────────────────────────────────────────────────────────
+// zsh and bash
+// ─────────────────────────────────────────────────────────────
+
+// shGitInfo sets the __promptly_git_* variables. It runs unchanged in zsh
+// and bash.
+const shGitInfo = `__promptly_git_info() {
+  __promptly_git_host= __promptly_git_branch= __promptly_git_sync=
+  __promptly_git_staged= __promptly_git_unstaged= __promptly_git_untracked= __promptly_git_stashed=
+  git rev-parse --git-dir > /dev/null 2>&1 || return 0
+
+  __promptly_git_branch=$(git symbolic-ref --short HEAD 2>/dev/null || git describe --tags --exact-match 2>/dev/null || echo "DETACHED")
+
+  # Single git status call with branch info
+  local line staged=0 unstaged=0 untracked=0
+  while IFS= read -r line; do
+    case "${line:0:2}" in
+      "##") ;;  # Branch info line
+      [AMDRCU]?) ((staged++)) ;;
+      ?[MD]) ((unstaged++)) ;;
+      "??") ((untracked++)) ;;
+    esac
+  done <<< "$(git status --porcelain -b 2>/dev/null)"
+
+  local stashed
+  stashed=$(git stash list 2>/dev/null | wc -l | tr -d ' ')
+
+  # Detect GitHub by remote URL
+  local remotes
+  remotes="$(git config --get remote.origin.url 2>/dev/null) $(git config --get remote.upstream.url 2>/dev/null)"
+  __promptly_git_host=$PROMPTLY_GIT_ICON
+  [[ $remotes == *github.com* ]] && __promptly_git_host=$PROMPTLY_GITHUB_ICON
+
+  # Ahead / behind
+  local counts ahead=0 behind=0
+  if counts=$(git rev-list --left-right --count 'HEAD...@{u}' 2>/dev/null); then
+    read -r ahead behind <<< "$counts"
+  fi
+  if (( ahead > 0 && behind > 0 )); then
+    __promptly_git_sync="${PROMPTLY_DIVERGED_ICON}${PROMPTLY_SYNC_SEPARATOR}${ahead}/${behind}"
+  elif (( ahead > 0 )); then
+    __promptly_git_sync="${PROMPTLY_AHEAD_ICON}${PROMPTLY_SYNC_SEPARATOR}${ahead}"
+  elif (( behind > 0 )); then
+    __promptly_git_sync="${PROMPTLY_BEHIND_ICON}${PROMPTLY_SYNC_SEPARATOR}${behind}"
+  fi
+
+  (( staged > 0 )) && __promptly_git_staged="${PROMPTLY_STAGED_ICON}${staged}"
+  (( unstaged > 0 )) && __promptly_git_unstaged="${PROMPTLY_UNSTAGED_ICON}${unstaged}"
+  (( untracked > 0 )) && __promptly_git_untracked="${PROMPTLY_UNTRACKED_ICON}${untracked}"
+  (( stashed > 0 )) && __promptly_git_stashed="${PROMPTLY_STASHED_ICON}${stashed}"
+  return 0
+}
+`
+
+// shQuote quotes s for zsh, bash and POSIX sh.
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func writeShIcons(b *strings.Builder, s ThemeSpec) {
+	icons := [][2]string{
+		{"GIT_ICON", s.Icons.Git},
+		{"GITHUB_ICON", s.Icons.GitHub},
+		{"AHEAD_ICON", s.Icons.Ahead},
+		{"BEHIND_ICON", s.Icons.Behind},
+		{"DIVERGED_ICON", s.Icons.Diverged},
+		{"STAGED_ICON", s.Icons.Staged},
+		{"UNSTAGED_ICON", s.Icons.Unstaged},
+		{"UNTRACKED_ICON", s.Icons.Untracked},
+		{"STASHED_ICON", s.Icons.Stashed},
+		{"SYNC_SEPARATOR", s.SyncSeparator},
+	}
+	for _, icon := range icons {
+		fmt.Fprintf(b, "PROMPTLY_%s=%s\n", icon[0], shQuote(icon[1]))
+	}
+	b.WriteString("\n")
+}
+
+// promptTexts collects the literal texts of a zsh or bash prompt. Both
+// shells expand $, ` and \ in the prompt string when they draw it, so any
+// literal containing those is kept in the __promptly_text array and only
+// referenced from the prompt.
+type promptTexts struct {
+	texts []string
+	base  int    // index of the first element: 1 in zsh, 0 in bash
+	zsh   bool   // zsh also needs % doubled
+	quote string // characters that force a literal into the array
+}
+
+func (t *promptTexts) literal(s string) string {
+	if s == "" {
+		return ""
+	}
+	if !strings.ContainsAny(s, t.quote) {
+		return strings.ReplaceAll(s, "'", `'\''`)
+	}
+	t.texts = append(t.texts, s)
+	ref := fmt.Sprintf("__promptly_text[%d]", t.base+len(t.texts)-1)
+	if t.zsh {
+		return "${" + ref + `//\%/%%}`
+	}
+	return "${" + ref + "}"
+}
+
+func (t *promptTexts) declaration() string {
+	if len(t.texts) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(t.texts))
+	for i, s := range t.texts {
+		quoted[i] = shQuote(s)
+	}
+	return "__promptly_text=(" + strings.Join(quoted, " ") + ")\n\n"
+}
+
+func compileZsh(name string, s ThemeSpec) string {
+	texts := &promptTexts{base: 1, zsh: true, quote: "%$`\\'!"}
+	color := func(c Color) string { return "%F{" + c.Zsh() + "}" }
+
+	var body strings.Builder
+	if s.BlankLine {
+		body.WriteString("  p+=$'\\n'\n")
+	}
+	for _, seg := range s.Segments {
+		switch seg.Type {
+		case segNewline:
+			body.WriteString("  p+=$'\\n'\n")
+		case segDir:
+			fmt.Fprintf(&body, "  p+='%s%s%%~%%f'\n", color(s.mustColor(seg.Color)), texts.literal(seg.Prefix))
+		case segText:
+			line := fmt.Sprintf("p+='%s%s%%f'", color(s.mustColor(seg.Color)), texts.literal(seg.Prefix+seg.Text))
+			writeShGated(&body, seg, line, "")
+		case segChar:
+			c := color(s.mustColor(seg.Color))
+			if seg.ErrorColor != "" {
+				c = "%(?." + c + "." + color(s.mustColor(seg.ErrorColor)) + ")"
+			}
+			fmt.Fprintf(&body, "  p+='%s%s%%f '\n", c, texts.literal(seg.Prefix+s.PromptChar))
+		default:
+			value := "__promptly_git_" + gitValues[seg.Type]
+			line := fmt.Sprintf("p+='%s%s${%s//\\%%/%%%%}%%f'", color(s.mustColor(seg.Color)), texts.literal(seg.Prefix), value)
+			writeShGated(&body, seg, line, value)
+		}
+	}
+
+	var b strings.Builder
+	writeHeader(&b, "#", name, ".promptly.zsh")
+	b.WriteString("# The prompt only refers to git values and texts by name, so nothing in a\n")
+	b.WriteString("# branch name is ever expanded or run.\n")
+	b.WriteString("setopt prompt_subst\n\n")
+	writeShIcons(&b, s)
+	b.WriteString(texts.declaration())
+	b.WriteString(shGitInfo)
+	b.WriteString("\n__promptly_build_prompt() {\n")
+	b.WriteString("  __promptly_git_info\n")
+	b.WriteString("  local p=''\n")
+	b.WriteString(body.String())
+	b.WriteString("  PROMPT=$p\n")
+	b.WriteString("}\n\n")
+	b.WriteString("autoload -Uz add-zsh-hook\n")
+	b.WriteString("add-zsh-hook precmd __promptly_build_prompt\n")
+	b.WriteString("__promptly_build_prompt\n")
+	return b.String()
+}
+
+// writeShGated writes a line of a zsh or bash prompt builder that is only
+// run inside a git repository and, for git values, when the value is set.
+func writeShGated(b *strings.Builder, seg SpecSegment, line, value string) {
+	switch {
+	case value != "" && seg.Type != segGitHost && seg.Type != segBranch:
+		fmt.Fprintf(b, "  [[ -n $%s ]] && %s\n", value, line)
+	case isGitSegment(seg):
+		fmt.Fprintf(b, "  [[ -n $__promptly_git_branch ]] && %s\n", line)
+	default:
+		fmt.Fprintf(b, "  %s\n", line)
+	}
+}
+
+func compileBash(name string, s ThemeSpec) string {
+	texts := &promptTexts{base: 0, quote: "$`\\'!"}
+	color := func(c Color) string { return `\[\e[` + c.SGR() + `m\]` }
+	const reset = `\[\e[0m\]`
+
+	var body strings.Builder
+	if s.BlankLine {
+		body.WriteString("  p+='\\n'\n")
+	}
+	for _, seg := range s.Segments {
+		switch seg.Type {
+		case segNewline:
+			body.WriteString("  p+='\\n'\n")
+		case segDir:
+			fmt.Fprintf(&body, "  p+='%s%s\\w%s'\n", color(s.mustColor(seg.Color)), texts.literal(seg.Prefix), reset)
+		case segText:
+			line := fmt.Sprintf("p+='%s%s%s'", color(s.mustColor(seg.Color)), texts.literal(seg.Prefix+seg.Text), reset)
+			writeShGated(&body, seg, line, "")
+		case segChar:
+			char := texts.literal(seg.Prefix + s.PromptChar)
+			if seg.ErrorColor != "" {
+				fmt.Fprintf(&body, "  if (( last_status == 0 )); then p+='%s'; else p+='%s'; fi\n",
+					color(s.mustColor(seg.Color)), color(s.mustColor(seg.ErrorColor)))
+				fmt.Fprintf(&body, "  p+='%s%s '\n", char, reset)
+			} else {
+				fmt.Fprintf(&body, "  p+='%s%s%s '\n", color(s.mustColor(seg.Color)), char, reset)
+			}
+		default:
+			value := "__promptly_git_" + gitValues[seg.Type]
+			line := fmt.Sprintf("p+='%s%s${%s}%s'", color(s.mustColor(seg.Color)), texts.literal(seg.Prefix), value, reset)
+			writeShGated(&body, seg, line, value)
+		}
+	}
+
+	var b strings.Builder
+	writeHeader(&b, "#", name, ".promptly.bash")
+	b.WriteString("# PS1 is rebuilt from PROMPT_COMMAND before every prompt. Color escapes are\n")
+	b.WriteString("# wrapped in \\[ \\] so readline knows they take up no space, and PS1 only\n")
+	b.WriteString("# refers to git values and texts by name, so nothing in a branch name is\n")
+	b.WriteString("# ever expanded or run.\n\n")
+	writeShIcons(&b, s)
+	b.WriteString(texts.declaration())
+	b.WriteString(shGitInfo)
+	b.WriteString("\n__promptly_build_prompt() {\n")
+	b.WriteString("  local last_status=$?\n")
+	b.WriteString("  __promptly_git_info\n")
+	b.WriteString("  local p=''\n")
+	b.WriteString(body.String())
+	b.WriteString("  PS1=$p\n")
+	b.WriteString("  return $last_status\n")
+	b.WriteString("}\n\n")
+	b.WriteString("# Run before every prompt without clobbering other PROMPT_COMMAND hooks\n")
+	b.WriteString("case \";${PROMPT_COMMAND:-};\" in\n")
+	b.WriteString("  *\";__promptly_build_prompt;\"*) ;;\n")
+	b.WriteString("  *) PROMPT_COMMAND=\"__promptly_build_prompt${PROMPT_COMMAND:+;$PROMPT_COMMAND}\" ;;\n")
+	b.WriteString("esac\n")
+	return b.String()
+}
+
+// ─────────────────────────────────────────────────────────────
+// fish
+// ─────────────────────────────────────────────────────────────
+
+const fishGitInfo = `function __promptly_git_info
+    set -g __promptly_git_host ''
+    set -g __promptly_git_branch ''
+    set -g __promptly_git_sync ''
+    set -g __promptly_git_staged ''
+    set -g __promptly_git_unstaged ''
+    set -g __promptly_git_untracked ''
+    set -g __promptly_git_stashed ''
+    git rev-parse --git-dir >/dev/null 2>&1; or return 0
+
+    set -g __promptly_git_branch (git symbolic-ref --short HEAD 2>/dev/null; \
+        or git describe --tags --exact-match 2>/dev/null; \
+        or echo DETACHED)
+
+    # Single git status call with branch info
+    set -l staged 0
+    set -l unstaged 0
+    set -l untracked 0
+    for line in (git status --porcelain -b 2>/dev/null)
+        set -l xy (string sub -l 2 -- $line)
+        if test "$xy" = '##'
+            continue
+        else if string match -qr '^[AMDRCU]' -- $xy
+            set staged (math $staged + 1)
+        else if string match -qr '^.[MD]' -- $xy
+            set unstaged (math $unstaged + 1)
+        else if test "$xy" = '??'
+            set untracked (math $untracked + 1)
+        end
+    end
+    set -l stashed (git stash list 2>/dev/null | count)
+
+    # Detect GitHub by remote URL
+    set -g __promptly_git_host $__promptly_git_icon
+    if string match -q '*github.com*' -- (git config --get remote.origin.url 2>/dev/null) (git config --get remote.upstream.url 2>/dev/null)
+        set -g __promptly_git_host $__promptly_github_icon
+    end
+
+    # Ahead / behind
+    set -l counts (git rev-list --left-right --count 'HEAD...@{u}' 2>/dev/null | string split \t)
+    if test (count $counts) -eq 2
+        set -l ahead $counts[1]
+        set -l behind $counts[2]
+        if test $ahead -gt 0 -a $behind -gt 0
+            set -g __promptly_git_sync "$__promptly_diverged_icon$__promptly_sync_separator$ahead/$behind"
+        else if test $ahead -gt 0
+            set -g __promptly_git_sync "$__promptly_ahead_icon$__promptly_sync_separator$ahead"
+        else if test $behind -gt 0
+            set -g __promptly_git_sync "$__promptly_behind_icon$__promptly_sync_separator$behind"
+        end
+    end
+
+    test $staged -gt 0; and set -g __promptly_git_staged "$__promptly_staged_icon$staged"
+    test $unstaged -gt 0; and set -g __promptly_git_unstaged "$__promptly_unstaged_icon$unstaged"
+    test $untracked -gt 0; and set -g __promptly_git_untracked "$__promptly_untracked_icon$untracked"
+    test $stashed -gt 0; and set -g __promptly_git_stashed "$__promptly_stashed_icon$stashed"
+    return 0
+end
+`
+
+// fishQuote quotes s for fish.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+func compileFish(name string, s ThemeSpec) string {
+	var body strings.Builder
+	if s.BlankLine {
+		body.WriteString("    echo\n")
+	}
+	for _, seg := range s.Segments {
+		var line string
+		switch seg.Type {
+		case segNewline:
+			body.WriteString("    echo\n")
+			continue
+		case segDir:
+			line = fmt.Sprintf("set_color %s; printf '%%s' %s", s.mustColor(seg.Color).Fish(),
+				joinParts(" ", fishQuote(seg.Prefix), "(string replace -r -- '^'(string escape --style=regex -- $HOME) '~' $PWD)"))
+		case segText:
+			line = fmt.Sprintf("set_color %s; printf '%%s' %s", s.mustColor(seg.Color).Fish(), fishQuote(seg.Prefix+seg.Text))
+		case segChar:
+			if seg.ErrorColor != "" {
+				fmt.Fprintf(&body, "    if test $last_status -eq 0; set_color %s; else; set_color %s; end\n",
+					s.mustColor(seg.Color).Fish(), s.mustColor(seg.ErrorColor).Fish())
+				fmt.Fprintf(&body, "    printf '%%s ' %s\n", fishQuote(seg.Prefix+s.PromptChar))
+			} else {
+				fmt.Fprintf(&body, "    set_color %s; printf '%%s ' %s\n", s.mustColor(seg.Color).Fish(), fishQuote(seg.Prefix+s.PromptChar))
+			}
+			continue
+		default:
+			value := "__promptly_git_" + gitValues[seg.Type]
+			line = fmt.Sprintf("set_color %s; printf '%%s' %s", s.mustColor(seg.Color).Fish(), joinParts(" ", fishQuote(seg.Prefix), "$"+value))
+			if seg.Type != segGitHost && seg.Type != segBranch {
+				fmt.Fprintf(&body, "    test -n \"$%s\"; and begin; %s; end\n", value, line)
+				continue
+			}
+		}
+		if isGitSegment(seg) {
+			fmt.Fprintf(&body, "    test -n \"$__promptly_git_branch\"; and begin; %s; end\n", line)
+		} else {
+			fmt.Fprintf(&body, "    %s\n", line)
+		}
+	}
+
+	var b strings.Builder
+	writeHeader(&b, "#", name, ".promptly.fish")
+	icons := [][2]string{
+		{"git_icon", s.Icons.Git},
+		{"github_icon", s.Icons.GitHub},
+		{"ahead_icon", s.Icons.Ahead},
+		{"behind_icon", s.Icons.Behind},
+		{"diverged_icon", s.Icons.Diverged},
+		{"staged_icon", s.Icons.Staged},
+		{"unstaged_icon", s.Icons.Unstaged},
+		{"untracked_icon", s.Icons.Untracked},
+		{"stashed_icon", s.Icons.Stashed},
+		{"sync_separator", s.SyncSeparator},
+	}
+	for _, icon := range icons {
+		fmt.Fprintf(&b, "set -g __promptly_%s %s\n", icon[0], fishQuote(icon[1]))
+	}
+	b.WriteString("\n")
+	b.WriteString(fishGitInfo)
+	b.WriteString("\nfunction fish_prompt\n")
+	b.WriteString("    set -l last_status $status\n")
+	b.WriteString("    __promptly_git_info\n")
+	b.WriteString(body.String())
+	b.WriteString("    set_color normal\n")
+	b.WriteString("end\n")
+	return b.String()
+}
+
+// ─────────────────────────────────────────────────────────────
+// PowerShell
+// ─────────────────────────────────────────────────────────────
+
+const pwshGitInfo = `function Get-PromptlyGitInfo {
+    git rev-parse --git-dir 2>$null | Out-Null
+    if ($LASTEXITCODE -ne 0) { return $null }
+
+    $branch = git symbolic-ref --short HEAD 2>$null
+    if (-not $branch) { $branch = git describe --tags --exact-match 2>$null }
+    if (-not $branch) { $branch = 'DETACHED' }
+
+    # Single git status call with branch info
+    $staged = 0; $unstaged = 0; $untracked = 0
+    foreach ($line in @(git status --porcelain -b 2>$null)) {
+        if ($line.Length -lt 2 -or $line.StartsWith('##')) { continue }
+        if ('AMDRCU'.Contains($line[0])) { $staged++ }
+        elseif ('MD'.Contains($line[1])) { $unstaged++ }
+        elseif ($line.StartsWith('??')) { $untracked++ }
+    }
+
+    $stashed = @(git stash list 2>$null).Count
+
+    # Detect GitHub by remote URL
+    $gitHost = $PromptlyIcons.Git
+    $remotes = "$(git config --get remote.origin.url 2>$null) $(git config --get remote.upstream.url 2>$null)"
+    if ($remotes -like '*github.com*') { $gitHost = $PromptlyIcons.GitHub }
+
+    # Ahead / behind
+    $sync = ''
+    $counts = git rev-list --left-right --count 'HEAD...@{u}' 2>$null
+    if ($LASTEXITCODE -eq 0 -and $counts) {
+        $ahead, $behind = -split $counts | ForEach-Object { [int]$_ }
+        $sep = $PromptlyIcons.SyncSeparator
+        if ($ahead -gt 0 -and $behind -gt 0) {
+            $sync = "$($PromptlyIcons.Diverged)$sep$ahead/$behind"
+        } elseif ($ahead -gt 0) {
+            $sync = "$($PromptlyIcons.Ahead)$sep$ahead"
+        } elseif ($behind -gt 0) {
+            $sync = "$($PromptlyIcons.Behind)$sep$behind"
+        }
+    }
+
+    [pscustomobject]@{
+        Host      = $gitHost
+        Branch    = $branch
+        Sync      = $sync
+        Staged    = if ($staged -gt 0) { "$($PromptlyIcons.Staged)$staged" } else { '' }
+        Unstaged  = if ($unstaged -gt 0) { "$($PromptlyIcons.Unstaged)$unstaged" } else { '' }
+        Untracked = if ($untracked -gt 0) { "$($PromptlyIcons.Untracked)$untracked" } else { '' }
+        Stashed   = if ($stashed -gt 0) { "$($PromptlyIcons.Stashed)$stashed" } else { '' }
+    }
+}
+`
+
+// pwshQuote quotes s as a verbatim PowerShell string.
+func pwshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func pwshColor(c Color) string {
+	return `"$([char]27)[` + c.SGR() + `m"`
+}
+
+func compilePwsh(name string, s ThemeSpec) string {
+	const reset = `"$([char]27)[0m"`
+
+	var body strings.Builder
+	if s.BlankLine {
+		body.WriteString("    $p += \"`n\"\n")
+	}
+	for _, seg := range s.Segments {
+		var line string
+		switch seg.Type {
+		case segNewline:
+			body.WriteString("    $p += \"`n\"\n")
+			continue
+		case segDir:
+			line = "$p += " + joinParts(" + ", pwshColor(s.mustColor(seg.Color)), pwshQuote(seg.Prefix), "$dir", reset)
+		case segText:
+			line = fmt.Sprintf("$p += %s + %s + %s", pwshColor(s.mustColor(seg.Color)), pwshQuote(seg.Prefix+seg.Text), reset)
+		case segChar:
+			c := pwshColor(s.mustColor(seg.Color))
+			if seg.ErrorColor != "" {
+				c = fmt.Sprintf("$(if ($lastSuccess) { %s } else { %s })", c, pwshColor(s.mustColor(seg.ErrorColor)))
+			}
+			fmt.Fprintf(&body, "    $p += %s + %s + %s + ' '\n", c, pwshQuote(seg.Prefix+s.PromptChar), reset)
+			continue
+		default:
+			field := strings.ToUpper(gitValues[seg.Type][:1]) + gitValues[seg.Type][1:]
+			line = "$p += " + joinParts(" + ", pwshColor(s.mustColor(seg.Color)), pwshQuote(seg.Prefix), "$git."+field, reset)
+			if seg.Type != segGitHost && seg.Type != segBranch {
+				fmt.Fprintf(&body, "    if ($git -and $git.%s) { %s }\n", field, line)
+				continue
+			}
+		}
+		if isGitSegment(seg) {
+			fmt.Fprintf(&body, "    if ($git) { %s }\n", line)
+		} else {
+			fmt.Fprintf(&body, "    %s\n", line)
+		}
+	}
+
+	var b strings.Builder
+	writeHeader(&b, "#", name, ".promptly.ps1")
+	b.WriteString("$PromptlyIcons = @{\n")
+	icons := [][2]string{
+		{"Git", s.Icons.Git},
+		{"GitHub", s.Icons.GitHub},
+		{"Ahead", s.Icons.Ahead},
+		{"Behind", s.Icons.Behind},
+		{"Diverged", s.Icons.Diverged},
+		{"Staged", s.Icons.Staged},
+		{"Unstaged", s.Icons.Unstaged},
+		{"Untracked", s.Icons.Untracked},
+		{"Stashed", s.Icons.Stashed},
+		{"SyncSeparator", s.SyncSeparator},
+	}
+	for _, icon := range icons {
+		fmt.Fprintf(&b, "    %-13s = %s\n", icon[0], pwshQuote(icon[1]))
+	}
+	b.WriteString("}\n\n")
+	b.WriteString(pwshGitInfo)
+	b.WriteString("\nfunction global:prompt {\n")
+	b.WriteString("    $lastSuccess = $?\n")
+	b.WriteString("    # git overwrites $LASTEXITCODE; put it back for the next command.\n")
+	b.WriteString("    $lastExit = $global:LASTEXITCODE\n\n")
+	b.WriteString("    $dir = $PWD.Path\n")
+	b.WriteString("    if ($dir.StartsWith($HOME)) { $dir = '~' + $dir.Substring($HOME.Length) }\n")
+	b.WriteString("    $git = Get-PromptlyGitInfo\n\n")
+	b.WriteString("    $p = ''\n")
+	b.WriteString(body.String())
+	b.WriteString("\n    $global:LASTEXITCODE = $lastExit\n")
+	b.WriteString("    $p\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// ─────────────────────────────────────────────────────────────
+// Nushell
+// ─────────────────────────────────────────────────────────────
+
+const nuGitInfo = `def promptly-git [...args: string] {
+    do { ^git ...$args } | complete
+}
+
+def promptly-git-info [] {
+    if (promptly-git rev-parse --git-dir).exit_code != 0 { return null }
+
+    let icons = $env.PROMPTLY_ICONS
+    mut branch = (promptly-git symbolic-ref --short HEAD).stdout | str trim
+    if $branch == "" { $branch = ((promptly-git describe --tags --exact-match).stdout | str trim) }
+    if $branch == "" { $branch = "DETACHED" }
+
+    # Single git status call with branch info
+    let changes = (promptly-git status --porcelain -b).stdout | lines | where {|l| not ($l | str starts-with "##") }
+    let staged = $changes | where {|l| $l =~ '^[AMDRCU]' } | length
+    let unstaged = $changes | where {|l| $l !~ '^[AMDRCU]' and $l =~ '^.[MD]' } | length
+    let untracked = $changes | where {|l| $l | str starts-with "??" } | length
+
+    let stashed = (promptly-git stash list).stdout | lines | length
+
+    # Detect GitHub by remote URL
+    let remotes = [
+        (promptly-git config --get remote.origin.url).stdout
+        (promptly-git config --get remote.upstream.url).stdout
+    ] | str join " "
+    let host = if ($remotes | str contains "github.com") { $icons.github } else { $icons.git }
+
+    # Ahead / behind
+    mut sync = ""
+    let counts = promptly-git rev-list --left-right --count "HEAD...@{u}"
+    if $counts.exit_code == 0 {
+        let n = $counts.stdout | str trim | split row -r '\s+' | into int
+        let sep = $icons.sync_separator
+        if $n.0 > 0 and $n.1 > 0 {
+            $sync = $icons.diverged ++ $sep ++ ($n.0 | into string) ++ "/" ++ ($n.1 | into string)
+        } else if $n.0 > 0 {
+            $sync = $icons.ahead ++ $sep ++ ($n.0 | into string)
+        } else if $n.1 > 0 {
+            $sync = $icons.behind ++ $sep ++ ($n.1 | into string)
+        }
+    }
+
+    {
+        host: $host
+        branch: $branch
+        sync: $sync
+        staged: (if $staged > 0 { $icons.staged ++ ($staged | into string) } else { "" })
+        unstaged: (if $unstaged > 0 { $icons.unstaged ++ ($unstaged | into string) } else { "" })
+        untracked: (if $untracked > 0 { $icons.untracked ++ ($untracked | into string) } else { "" })
+        stashed: (if $stashed > 0 { $icons.stashed ++ ($stashed | into string) } else { "" })
+    }
+}
+`
+
+// nuQuote quotes s as a Nushell string.
+func nuQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func nuColor(c Color) string {
+	return `"\e[` + c.SGR() + `m"`
+}
+
+func compileNu(name string, s ThemeSpec) string {
+	const reset = `"\e[0m"`
+
+	var body strings.Builder
+	if s.BlankLine {
+		body.WriteString("    $p ++= \"\\n\"\n")
+	}
+	for _, seg := range s.Segments {
+		var line string
+		switch seg.Type {
+		case segNewline:
+			body.WriteString("    $p ++= \"\\n\"\n")
+			continue
+		case segDir:
+			line = "$p ++= (" + joinParts(" ++ ", nuColor(s.mustColor(seg.Color)), nuQuote(seg.Prefix), "$dir", reset) + ")"
+		case segText:
+			line = fmt.Sprintf("$p ++= (%s ++ %s ++ %s)", nuColor(s.mustColor(seg.Color)), nuQuote(seg.Prefix+seg.Text), reset)
+		case segChar:
+			c := nuColor(s.mustColor(seg.Color))
+			if seg.ErrorColor != "" {
+				c = fmt.Sprintf("(if $env.LAST_EXIT_CODE == 0 { %s } else { %s })", c, nuColor(s.mustColor(seg.ErrorColor)))
+			}
+			fmt.Fprintf(&body, "    $p ++= (%s ++ %s ++ %s ++ \" \")\n", c, nuQuote(seg.Prefix+s.PromptChar), reset)
+			continue
+		default:
+			field := gitValues[seg.Type]
+			line = "$p ++= (" + joinParts(" ++ ", nuColor(s.mustColor(seg.Color)), nuQuote(seg.Prefix), "$git."+field, reset) + ")"
+			if seg.Type != segGitHost && seg.Type != segBranch {
+				fmt.Fprintf(&body, "    if $git != null { if $git.%s != \"\" { %s } }\n", field, line)
+				continue
+			}
+		}
+		if isGitSegment(seg) {
+			fmt.Fprintf(&body, "    if $git != null { %s }\n", line)
+		} else {
+			fmt.Fprintf(&body, "    %s\n", line)
+		}
+	}
+
+	var b strings.Builder
+	writeHeader(&b, "#", name, ".promptly.nu")
+	b.WriteString("$env.PROMPTLY_ICONS = {\n")
+	icons := [][2]string{
+		{"git", s.Icons.Git},
+		{"github", s.Icons.GitHub},
+		{"ahead", s.Icons.Ahead},
+		{"behind", s.Icons.Behind},
+		{"diverged", s.Icons.Diverged},
+		{"staged", s.Icons.Staged},
+		{"unstaged", s.Icons.Unstaged},
+		{"untracked", s.Icons.Untracked},
+		{"stashed", s.Icons.Stashed},
+		{"sync_separator", s.SyncSeparator},
+	}
+	for _, icon := range icons {
+		fmt.Fprintf(&b, "    %s: %s\n", icon[0], nuQuote(icon[1]))
+	}
+	b.WriteString("}\n\n")
+	b.WriteString(nuGitInfo)
+	b.WriteString("\n$env.PROMPT_COMMAND = {||\n")
+	b.WriteString("    let home = $nu.home-path\n")
+	b.WriteString("    let dir = if ($env.PWD | str starts-with $home) { $env.PWD | str replace $home \"~\" } else { $env.PWD }\n")
+	b.WriteString("    let git = promptly-git-info\n\n")
+	b.WriteString("    mut p = \"\"\n")
+	b.WriteString(body.String())
+	b.WriteString("    $p\n")
+	b.WriteString("}\n")
+	b.WriteString("$env.PROMPT_COMMAND_RIGHT = {|| \"\" }\n")
+	b.WriteString("$env.PROMPT_INDICATOR = {|| \"\" }\n")
+	return b.String()
+}
+
+// ─────────────────────────────────────────────────────────────
+// starship
+// ─────────────────────────────────────────────────────────────
+
+// tomlQuote quotes s as a TOML basic string.
+func tomlQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, "\\u%04X", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// starshipText escapes s for use as literal text in a starship format
+// string.
+func starshipText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`\$[]()`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// starshipStyled returns text in a starship format string drawn in c.
+func starshipStyled(text string, c Color) string {
+	if text == "" {
+		return ""
+	}
+	return "[" + text + "](" + c.Starship() + ")"
+}
+
+// gitStatusVars maps status segments to starship git_status variables.
+var gitStatusVars = map[string]string{
+	segSync:      "$ahead_behind",
+	segStaged:    "$staged",
+	segUnstaged:  "$modified",
+	segUntracked: "$untracked",
+	segStashed:   "$stashed",
+}
+
+// compileStarship compiles s into a starship config. Its colors are never
+// downsampled: starship does that for the terminal it runs in.
+func compileStarship(name string, s ThemeSpec) string {
+	// Text that is only shown in git repositories can't stand on its own in
+	// starship, so it becomes part of the git_host or git_branch module next
+	// to it.
+	before := make(map[int]string)
+	after := make(map[int]string)
+	for i, seg := range s.Segments {
+		if seg.Type != segText || !seg.Git {
+			continue
+		}
+		text := starshipStyled(starshipText(seg.Prefix+seg.Text), s.exactColor(seg.Color))
+		if anchor := s.gitTextAnchor(i); anchor > i {
+			before[anchor] += text
+		} else {
+			after[anchor] += text
+		}
+	}
+
+	var format strings.Builder
+	var tables strings.Builder
+	statusDone := false
+	allDone := !s.Starship.All
+
+	for i, seg := range s.Segments {
+		c := Color{}
+		if seg.Type != segNewline {
+			c = s.exactColor(seg.Color)
+		}
+		prefix := starshipText(seg.Prefix)
+
+		switch seg.Type {
+		case segDir:
+			format.WriteString("$directory")
+			fmt.Fprintf(&tables, "[directory]\nformat = %s\nstyle = %s\n\n",
+				tomlQuote(starshipStyled(prefix+"$path", c)+"[$read_only]($read_only_style)"), tomlQuote(c.Starship()))
+		case segText:
+			if !seg.Git {
+				format.WriteString(starshipStyled(starshipText(seg.Prefix+seg.Text), c))
+			}
+		case segGitHost:
+			format.WriteString("${custom.promptly_git_host}")
+			command := fmt.Sprintf(`case "$(git config --get remote.origin.url; git config --get remote.upstream.url)" in *github.com*) printf '%%s' %s ;; *) printf '%%s' %s ;; esac`,
+				shQuote(s.Icons.GitHub), shQuote(s.Icons.Git))
+			fmt.Fprintf(&tables, "[custom.promptly_git_host]\ncommand = %s\nwhen = \"git rev-parse --is-inside-work-tree\"\nshell = [\"sh\"]\nformat = %s\n\n",
+				tomlQuote(command), tomlQuote(before[i]+starshipStyled(prefix+"$output", c)+after[i]))
+		case segBranch:
+			format.WriteString("$git_branch")
+			fmt.Fprintf(&tables, "[git_branch]\nformat = %s\n\n",
+				tomlQuote(before[i]+starshipStyled(prefix+"$branch", c)+after[i]))
+		case segSync, segStaged, segUnstaged, segUntracked, segStashed:
+			if !statusDone {
+				format.WriteString("$git_status")
+				statusDone = true
+				writeStarshipStatus(&tables, s)
+			}
+		case segNewline:
+			if !allDone {
+				format.WriteString("$all")
+				allDone = true
+			}
+			format.WriteString("$line_break")
+		case segChar:
+			errColor := c
+			if seg.ErrorColor != "" {
+				errColor = s.exactColor(seg.ErrorColor)
+			}
+			char := starshipText(seg.Prefix + s.PromptChar)
+			format.WriteString("$character")
+			fmt.Fprintf(&tables, "[character]\nsuccess_symbol = %s\nerror_symbol = %s\n\n",
+				tomlQuote(starshipStyled(char, c)), tomlQuote(starshipStyled(char, errColor)))
+		}
+	}
+	if !allDone {
+		format.WriteString("$all")
+	}
+
+	var b strings.Builder
+	writeHeader(&b, "#", name, ".promptly.toml")
+	b.WriteString("\"$schema\" = 'https://starship.rs/config-schema.json'\n\n")
+	fmt.Fprintf(&b, "format = %s\n", tomlQuote(format.String()))
+	fmt.Fprintf(&b, "add_newline = %t\n", s.BlankLine)
+	// The spec's color roles become a starship palette, so Extra can
+	// refer to them by name too. Roles named after the color they are
+	// don't need an entry.
+	var roles []string
+	for role := range s.Colors {
+		if s.exactColor(role).Starship() != role {
+			roles = append(roles, role)
+		}
+	}
+	if len(roles) > 0 {
+		sort.Strings(roles)
+		b.WriteString("palette = \"promptly\"\n\n[palettes.promptly]\n")
+		for _, role := range roles {
+			fmt.Fprintf(&b, "%s = %s\n", role, tomlQuote(s.exactColor(role).Starship()))
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(tables.String())
+	if extra := strings.TrimSpace(s.Starship.Extra); extra != "" {
+		b.WriteString(extra + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+func writeStarshipStatus(b *strings.Builder, s ThemeSpec) {
+	var format strings.Builder
+	symbols := make(map[string]string)
+	for _, seg := range s.Segments {
+		if !isStatusSegment(seg) {
+			continue
+		}
+		format.WriteString(gitStatusVars[seg.Type])
+		c := s.exactColor(seg.Color)
+		prefix := starshipText(seg.Prefix)
+		sep := starshipText(s.SyncSeparator)
+		switch seg.Type {
+		case segSync:
+			symbols["ahead"] = starshipStyled(prefix+starshipText(s.Icons.Ahead)+sep+"$count", c)
+			symbols["behind"] = starshipStyled(prefix+starshipText(s.Icons.Behind)+sep+"$count", c)
+			symbols["diverged"] = starshipStyled(prefix+starshipText(s.Icons.Diverged)+sep+"$ahead_count/$behind_count", c)
+		case segStaged:
+			symbols["staged"] = starshipStyled(prefix+starshipText(s.Icons.Staged)+"$count", c)
+		case segUnstaged:
+			symbols["modified"] = starshipStyled(prefix+starshipText(s.Icons.Unstaged)+"$count", c)
+		case segUntracked:
+			symbols["untracked"] = starshipStyled(prefix+starshipText(s.Icons.Untracked)+"$count", c)
+		case segStashed:
+			symbols["stashed"] = starshipStyled(prefix+starshipText(s.Icons.Stashed)+"$count", c)
+		}
+	}
+
+	fmt.Fprintf(b, "[git_status]\nformat = %s\n", tomlQuote(format.String()))
+	for _, key := range []string{"ahead", "behind", "diverged", "staged", "modified", "untracked", "stashed"} {
+		if symbol, ok := symbols[key]; ok {
+			fmt.Fprintf(b, "%s = %s\n", key, tomlQuote(symbol))
+		}
+	}
+	b.WriteString("\n")
+}
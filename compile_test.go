@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/BurntSushi/toml"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// truecolor compiles themes with the spec's own colors for the rest of the
+// test, whatever terminal the test runs in.
+func truecolor(t *testing.T) {
+	t.Helper()
+	colorsOverride = DepthTrue
+	t.Cleanup(func() { colorsOverride = DepthAuto })
+}
+
+// TestCompileGolden compares the default theme compiled for every shell with
+// testdata/default.promptly.*. Run go test -update to accept changes.
+func TestCompileGolden(t *testing.T) {
+	truecolor(t)
+	contents := compileTheme("default", builtinSpecs(t)["default"])
+	for _, shell := range compiledTargets {
+		golden := filepath.Join("testdata", "default"+themeSuffixes[shell])
+		if *update {
+			if err := os.WriteFile(golden, []byte(contents[shell]), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if want := readString(t, golden); contents[shell] != want {
+			t.Errorf("%s differs from %s:\n%s", shell, golden, unifiedDiff(golden, "compiled", want, contents[shell]))
+		}
+	}
+}
+
+// TestCompileSyntax checks every built-in theme compiled for every shell
+// that can be checked here.
+func TestCompileSyntax(t *testing.T) {
+	truecolor(t)
+	checkers := map[ShellTarget][]string{
+		ShellBash: {"bash", "-n"},
+		ShellZsh:  {"zsh", "-n"},
+		ShellFish: {"fish", "--no-execute"},
+	}
+	for name, spec := range builtinSpecs(t) {
+		contents := compileTheme(name, spec)
+		t.Run(name, func(t *testing.T) {
+			var config map[string]any
+			if _, err := toml.Decode(contents[ShellStarship], &config); err != nil {
+				t.Errorf("starship: %v", err)
+			}
+			for shell, checker := range checkers {
+				if _, err := exec.LookPath(checker[0]); err != nil {
+					continue
+				}
+				file := filepath.Join(t.TempDir(), name+themeSuffixes[shell])
+				if err := os.WriteFile(file, []byte(contents[shell]), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if out, err := exec.Command(checker[0], append(checker[1:], file)...).CombinedOutput(); err != nil {
+					t.Errorf("%s: %v\n%s", shell, err, out)
+				}
+			}
+		})
+	}
+}
+
+// TestGeneratedRoundTrip checks that compiled files are marked as generated,
+// so the loader compiles the spec again instead of reading them, while a
+// hand-written variant next to the spec replaces its compiled one.
+func TestGeneratedRoundTrip(t *testing.T) {
+	truecolor(t)
+	spec := readString(t, "default"+specSuffix)
+	contents := compileTheme("default", builtinSpecs(t)["default"])
+	for _, shell := range compiledTargets {
+		if !isGenerated(contents[shell]) {
+			t.Errorf("%s isn't marked as generated", shell)
+		}
+	}
+
+	const handWritten = "function fish_prompt; echo '> '; end\n"
+	stale := strings.Replace(contents[ShellZsh], "__promptly_build_prompt", "__stale", 1)
+	fsys := fstest.MapFS{
+		"mine" + specSuffix:               {Data: []byte(spec)},
+		"mine" + themeSuffixes[ShellZsh]:  {Data: []byte(stale)},
+		"mine" + themeSuffixes[ShellFish]: {Data: []byte(handWritten)},
+	}
+	themes, err := readThemes(fsys, Theme{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mine := themes["mine"]
+	if got, want := mine.Contents[ShellZsh], compileTheme("mine", *mine.Spec)[ShellZsh]; got != want {
+		t.Errorf("the stale compiled zsh file was read instead of the spec:\n%s", got)
+	}
+	if got := mine.Contents[ShellFish]; got != handWritten || isGenerated(got) {
+		t.Errorf("the hand-written fish variant was replaced:\n%s", got)
+	}
+}
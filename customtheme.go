@@ -0,0 +1,421 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Custom theme management
+// ─────────────────────────────────────────────────────────────
+
+// errThemesChanged is returned by selectTheme after a custom theme was
+// renamed, copied or deleted from the selector.
+var errThemesChanged = errors.New("custom themes changed")
+
+// stubShells are the shells whose install writes a stub that sources a
+// custom theme from the config directory.
+var stubShells = []ShellTarget{ShellZsh, ShellBash, ShellFish, ShellPwsh, ShellNu}
+
+// stubPath returns the file an install for shell writes the theme, or the
+// stub sourcing a custom theme, to.
+func stubPath(paths Paths, shell ShellTarget) string {
+	switch shell {
+	case ShellZsh:
+		return paths.ZshTheme
+	case ShellBash:
+		return paths.BashTheme
+	}
+	return filepath.Join(paths.Config, "promptly"+strings.TrimPrefix(themeSuffixes[shell], ".promptly"))
+}
+
+// customThemeFiles returns the paths of the files in the config directory
+// that make up the custom theme name: its spec, manifest and shell variants.
+func customThemeFiles(paths Paths, name string) ([]string, error) {
+	suffixes := []string{specSuffix, metaSuffix}
+	for _, suffix := range themeSuffixes {
+		suffixes = append(suffixes, suffix)
+	}
+
+	var files []string
+	for _, suffix := range suffixes {
+		path := filepath.Join(paths.Config, name+suffix)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// starshipRefs returns the ways an rc file refers to the starship config at
+// tomlPath: quoted for zsh, bash, fish and pwsh, and for Nushell.
+func starshipRefs(homeDir, tomlPath string) []string {
+	refs := []string{nuPath(homeDir, tomlPath)}
+	for _, shell := range []ShellTarget{ShellZsh, ShellBash, ShellFish, ShellPwsh} {
+		refs = append(refs, shellPath(shell, homeDir, tomlPath))
+	}
+	return refs
+}
+
+// installedShells returns the shells the custom theme name is currently
+// installed for.
+func installedShells(paths Paths, name string) ([]string, error) {
+	var shells []string
+	for _, shell := range stubShells {
+		data, err := os.ReadFile(stubPath(paths, shell))
+		if err != nil {
+			continue
+		}
+		themePath := filepath.Join(paths.Config, name+themeSuffixes[shell])
+		if string(data) == customStub(shell, paths.Home, themePath) || isNativeStubFor(string(data), name) {
+			shells = append(shells, string(shell))
+		}
+	}
+
+	refs := starshipRefs(paths.Home, filepath.Join(paths.Config, name+themeSuffixes[ShellStarship]))
+	for _, rcPath := range paths.RCFiles() {
+		rc, err := readRCFile(rcPath)
+		if err != nil {
+			return nil, err
+		}
+		block, err := rc.Block()
+		if err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(refs, func(ref string) bool { return strings.Contains(block, ref) }) {
+			shells = append(shells, string(ShellStarship))
+			break
+		}
+	}
+	return shells, nil
+}
+
+// manifestName matches the name line of a manifest, so renaming a theme
+// keeps the rest of the file as it was written.
+var manifestName = regexp.MustCompile(`(?m)^name\s*=\s*".*"[ \t]*$`)
+
+// retitle rewrites the places a custom theme's file names the theme: the
+// name in its manifest and the header of its spec or a compiled variant.
+func retitle(data []byte, suffix, oldName, newName string) []byte {
+	content := string(data)
+	switch {
+	case suffix == metaSuffix:
+		content = manifestName.ReplaceAllLiteralString(content, "name = "+tomlQuote(newName))
+	case suffix == specSuffix:
+		if rest, ok := strings.CutPrefix(content, "# "+oldName+specSuffix+"\n"); ok {
+			content = "# " + newName + specSuffix + "\n" + rest
+		}
+	case isGenerated(content):
+		content = strings.Replace(content, " "+oldName+suffix+"\n", " "+newName+suffix+"\n", 1)
+		content = strings.Replace(content, generatedMarker+oldName+specSuffix, generatedMarker+newName+specSuffix, 1)
+	}
+	return []byte(content)
+}
+
+// copyThemeFiles writes a copy of every file of the custom theme oldName
+// under newName.
+func copyThemeFiles(tx *Transaction, paths Paths, oldName, newName string) ([]string, error) {
+	files, err := customThemeFiles(paths, oldName)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("custom theme %q has no files in %s", oldName, paths.Config)
+	}
+	for _, path := range files {
+		suffix := strings.TrimPrefix(filepath.Base(path), oldName)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		target := filepath.Join(paths.Config, newName+suffix)
+		if err := tx.WriteFile(target, retitle(data, suffix, oldName, newName), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+	}
+	return files, nil
+}
+
+// relinkInstalled points the stubs and starship rc entries that use the
+// custom theme oldName at newName instead. It returns the files it changed.
+func relinkInstalled(tx *Transaction, paths Paths, oldName, newName string) ([]string, error) {
+	var changed []string
+	for _, shell := range stubShells {
+		path := stubPath(paths, shell)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var newStub string
+		switch {
+		case string(data) == customStub(shell, paths.Home, filepath.Join(paths.Config, oldName+themeSuffixes[shell])):
+			newStub = customStub(shell, paths.Home, filepath.Join(paths.Config, newName+themeSuffixes[shell]))
+		case isNativeStubFor(string(data), oldName):
+			newStub = strings.Replace(string(data), "--theme "+oldName, "--theme "+newName, 1)
+		default:
+			continue
+		}
+		if err := tx.WriteFile(path, []byte(newStub), 0644); err != nil {
+			return changed, err
+		}
+		changed = append(changed, path)
+	}
+
+	oldRefs := starshipRefs(paths.Home, filepath.Join(paths.Config, oldName+themeSuffixes[ShellStarship]))
+	newRefs := starshipRefs(paths.Home, filepath.Join(paths.Config, newName+themeSuffixes[ShellStarship]))
+	for _, rcPath := range paths.RCFiles() {
+		rc, err := readRCFile(rcPath)
+		if err != nil {
+			return changed, err
+		}
+		for i := range oldRefs {
+			if _, err := rc.ReplaceInBlock(oldRefs[i], newRefs[i]); err != nil {
+				return changed, err
+			}
+		}
+		if !rc.Changed() {
+			continue
+		}
+		if err := rc.Save(tx); err != nil {
+			return changed, err
+		}
+		changed = append(changed, rcPath)
+	}
+	return changed, nil
+}
+
+// checkNewThemeName checks that name can be given to a renamed or copied
+// custom theme: it must be valid and not taken by any theme.
+func checkNewThemeName(name string, themes []Theme) error {
+	if err := validateThemeName(name, themes); err != nil {
+		return err
+	}
+	if _, exists := findCustomTheme(themes, name); exists {
+		return fmt.Errorf("a custom theme named %q already exists", name)
+	}
+	return nil
+}
+
+// lookupCustomTheme finds the custom theme name, with an error that says
+// why when it isn't one.
+func lookupCustomTheme(themes []Theme, name string) (Theme, error) {
+	if t, ok := findCustomTheme(themes, name); ok {
+		return t, nil
+	}
+	if _, err := findTheme(themes, name); err == nil {
+		return Theme{}, fmt.Errorf("%q is a built-in theme; only custom themes can be changed", name)
+	}
+	return Theme{}, fmt.Errorf("custom theme %q not found", name)
+}
+
+// applyThemeChange runs fn as one transaction, or with dryRun prints the
+// changes it would make as a diff.
+func applyThemeChange(dryRun bool, fn func(tx *Transaction) error) error {
+	if !dryRun {
+		return runTransaction(fn)
+	}
+	changes, err := planTransaction(fn)
+	if err != nil {
+		return err
+	}
+	printPlan(os.Stdout, changes)
+	return nil
+}
+
+// renameCustomTheme renames every file of a custom theme and repoints an
+// install of it at the new name.
+func renameCustomTheme(oldName, newName string, dryRun bool) error {
+	paths, err := resolvePaths()
+	if err != nil {
+		return err
+	}
+
+	var relinked []string
+	err = applyThemeChange(dryRun, func(tx *Transaction) error {
+		files, err := copyThemeFiles(tx, paths, oldName, newName)
+		if err != nil {
+			return err
+		}
+		for _, path := range files {
+			if err := tx.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+		relinked, err = relinkInstalled(tx, paths, oldName, newName)
+		return err
+	})
+	if err != nil || dryRun {
+		return err
+	}
+
+	color.Green("✓ Renamed custom theme '%s' to '%s'.", oldName, newName)
+	for _, path := range relinked {
+		fmt.Printf("Updated %s to use the new name.\n", displayPath(paths.Home, path))
+	}
+	return nil
+}
+
+// copyCustomTheme copies every file of a custom theme to a new name.
+func copyCustomTheme(oldName, newName string, dryRun bool) error {
+	paths, err := resolvePaths()
+	if err != nil {
+		return err
+	}
+
+	err = applyThemeChange(dryRun, func(tx *Transaction) error {
+		_, err := copyThemeFiles(tx, paths, oldName, newName)
+		return err
+	})
+	if err != nil || dryRun {
+		return err
+	}
+
+	color.Green("✓ Copied custom theme '%s' to '%s'.", oldName, newName)
+	return nil
+}
+
+// deleteCustomTheme removes every file of a custom theme. Shells it is
+// installed for are left sourcing a file that no longer exists, so the
+// caller has to confirm that first.
+func deleteCustomTheme(name string, installed []string, dryRun bool) error {
+	paths, err := resolvePaths()
+	if err != nil {
+		return err
+	}
+
+	err = applyThemeChange(dryRun, func(tx *Transaction) error {
+		files, err := customThemeFiles(paths, name)
+		if err != nil {
+			return err
+		}
+		for _, path := range files {
+			if err := tx.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+		return nil
+	})
+	if err != nil || dryRun {
+		return err
+	}
+
+	color.Green("✓ Deleted custom theme '%s'.", name)
+	if len(installed) > 0 {
+		color.Yellow("! It was installed for %s. Install another theme to fix your prompt.", strings.Join(installed, ", "))
+	}
+	return nil
+}
+
+// manageCustomThemes lets the user pick a custom theme, for any shell, and
+// edit, rename, copy or delete it. An edited theme is saved with its variant
+// for shell.
+func manageCustomThemes(shell ShellTarget, dryRun bool) error {
+	customs, err := loadCustomThemes()
+	if err != nil {
+		return err
+	}
+	if len(customs) == 0 {
+		return errors.New("no custom themes found")
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}:",
+		Active:   "▸ {{ .Name | cyan }} - {{ .Description }}",
+		Inactive: "  {{ .Name | cyan }} - {{ .Description }}",
+		Selected: "{{ .Name | red | cyan }}",
+		Details:  themeDetails,
+	}
+	prompt := promptui.Select{
+		Label:     "Select a custom theme",
+		Items:     customs,
+		Templates: templates,
+		Size:      len(customs),
+	}
+	i, _, err := prompt.Run()
+	if err != nil {
+		return err
+	}
+	theme := customs[i]
+
+	items := []string{"Rename", "Copy", "Delete", "Cancel"}
+	if theme.Spec != nil {
+		items = append([]string{"Edit"}, items...)
+	}
+	actions := promptui.Select{
+		Label: fmt.Sprintf("What do you want to do with '%s'", theme.Name),
+		Items: items,
+	}
+	_, action, err := actions.Run()
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "Edit":
+		edited, err := editCustomTheme(theme)
+		if errors.Is(err, errDiscarded) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return saveCustomTheme(edited, shell, dryRun)
+
+	case "Rename", "Copy":
+		// Names are checked against every theme, not just the ones for
+		// this shell.
+		all, err := loadThemes()
+		if err != nil {
+			return err
+		}
+		prompt := promptui.Prompt{
+			Label: "New name",
+			Validate: func(name string) error {
+				return checkNewThemeName(name, all)
+			},
+		}
+		newName, err := prompt.Run()
+		if err != nil {
+			return err
+		}
+		if action == "Rename" {
+			return renameCustomTheme(theme.Name, newName, dryRun)
+		}
+		return copyCustomTheme(theme.Name, newName, dryRun)
+
+	case "Delete":
+		paths, err := resolvePaths()
+		if err != nil {
+			return err
+		}
+		installed, err := installedShells(paths, theme.Name)
+		if err != nil {
+			return err
+		}
+		label := fmt.Sprintf("Delete '%s'", theme.Name)
+		if len(installed) > 0 {
+			label = fmt.Sprintf("'%s' is installed for %s, which will break until you install another theme. Delete it anyway", theme.Name, strings.Join(installed, ", "))
+		}
+		confirm := promptui.Prompt{Label: label, IsConfirm: true}
+		if _, err := confirm.Run(); err != nil {
+			if errors.Is(err, promptui.ErrAbort) {
+				return nil
+			}
+			return err
+		}
+		return deleteCustomTheme(theme.Name, installed, dryRun)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRetitle(t *testing.T) {
+	spec := builtinSpecs(t)["default"]
+	compiled := compileTheme("mine", spec)[ShellZsh]
+	tests := []struct {
+		name, suffix, content, want string
+	}{
+		{"manifest", metaSuffix, "name = \"mine\"\nbase = \"mine\"\n", "name = \"ours\"\nbase = \"mine\"\n"},
+		{"spec", specSuffix, "# mine" + specSuffix + "\n# mine, my theme\n", "# ours" + specSuffix + "\n# mine, my theme\n"},
+		{"compiled", ".promptly.zsh", compiled, compileTheme("ours", spec)[ShellZsh]},
+		{"hand-written", ".promptly.zsh", "# mine.promptly.zsh\nPROMPT='mine '\n", "# mine.promptly.zsh\nPROMPT='mine '\n"},
+	}
+	for _, tt := range tests {
+		if got := string(retitle([]byte(tt.content), tt.suffix, "mine", "ours")); got != tt.want {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// installMine installs a custom theme called mine for zsh as a stub, for
+// bash as a native hook and for starship on fish.
+func installMine(t *testing.T) {
+	t.Helper()
+	themes, err := loadThemes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := findTheme(themes, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mine, err := createCustomTheme(base, ShellZsh, "mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compiled := compileTheme("mine", *mine.Spec)
+	mine.Contents[ShellBash] = compiled[ShellBash]
+	mine.Contents[ShellStarship] = compiled[ShellStarship]
+
+	installs := []struct {
+		shell ShellTarget
+		opts  InstallOptions
+	}{
+		{ShellZsh, InstallOptions{}},
+		{ShellBash, InstallOptions{Native: true}},
+		{ShellStarship, InstallOptions{StarshipShell: "fish"}},
+	}
+	for _, install := range installs {
+		if err := installTheme(mine, install.shell, install.opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInstalledShells(t *testing.T) {
+	paths := useHome(t)
+	installMine(t)
+	got, err := installedShells(paths, "mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"zsh", "bash", "starship"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, err := installedShells(paths, "min"); err != nil || len(got) != 0 {
+		t.Errorf("a theme that isn't installed is installed for %v, %v", got, err)
+	}
+}
+
+func TestRenameInstalledCustomTheme(t *testing.T) {
+	paths := useHome(t)
+	installMine(t)
+	if err := renameCustomTheme("mine", "ours", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if files, err := customThemeFiles(paths, "mine"); err != nil || len(files) != 0 {
+		t.Errorf("left behind %v, %v", files, err)
+	}
+	if got := readString(t, filepath.Join(paths.Config, "ours"+metaSuffix)); !strings.Contains(got, `name = "ours"`) {
+		t.Errorf("the manifest wasn't retitled:\n%s", got)
+	}
+	if got, want := readString(t, paths.ZshTheme), customStub(ShellZsh, paths.Home, filepath.Join(paths.Config, "ours.promptly.zsh")); got != want {
+		t.Errorf("the zsh stub wasn't relinked:\n%s", got)
+	}
+	if got := readString(t, paths.BashTheme); !isNativeStubFor(got, "ours") {
+		t.Errorf("the bash hook wasn't relinked:\n%s", got)
+	}
+	rc, err := readRCFile(paths.FishConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := rc.Block()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(block, "ours.promptly.toml") || strings.Contains(block, "mine.promptly.toml") {
+		t.Errorf("the managed block still sources the old starship config:\n%s", block)
+	}
+
+	got, err := installedShells(paths, "ours")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"zsh", "bash", "starship"}; !slices.Equal(got, want) {
+		t.Errorf("after the rename installed for %v, want %v", got, want)
+	}
+}
+
+func TestCopyAndDeleteCustomTheme(t *testing.T) {
+	paths := useHome(t)
+	installMine(t)
+	stub := readString(t, paths.ZshTheme)
+
+	if err := copyCustomTheme("mine", "ours", false); err != nil {
+		t.Fatal(err)
+	}
+	mine, _ := customThemeFiles(paths, "mine")
+	ours, _ := customThemeFiles(paths, "ours")
+	if len(mine) == 0 || len(ours) != len(mine) {
+		t.Errorf("copied %v to %v", mine, ours)
+	}
+	if got := readString(t, paths.ZshTheme); got != stub {
+		t.Errorf("copying relinked the install:\n%s", got)
+	}
+
+	if err := deleteCustomTheme("mine", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := customThemeFiles(paths, "mine"); len(files) != 0 {
+		t.Errorf("left behind %v", files)
+	}
+	if _, err := os.Stat(ours[0]); err != nil {
+		t.Errorf("deleted the copy too: %v", err)
+	}
+}
+
+func TestCreateCustomThemeMissingVariant(t *testing.T) {
+	useHome(t)
+	base := Theme{Name: "zsh-only", Contents: map[ShellTarget]string{ShellZsh: "PROMPT='%~ '\n"}}
+	_, err := createCustomTheme(base, ShellFish, "mine")
+	if err == nil || !strings.Contains(err.Error(), "no fish variant") {
+		t.Errorf("createCustomTheme from a theme without a fish variant = %v", err)
+	}
+}
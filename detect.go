@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Shell detection
+// ─────────────────────────────────────────────────────────────
+
+// Detection is what promptly could work out about the shell it is being run
+// from, so menus can preselect it and non-interactive installs can skip the
+// question.
+type Detection struct {
+	// Shell is the detected shell, or "" if it couldn't be determined.
+	Shell ShellTarget
+	// Source explains how Shell was found: "parent process", "$SHELL" or
+	// "rc files".
+	Source string
+	// Starship reports whether a starship binary is on $PATH.
+	Starship bool
+}
+
+// maxAncestors bounds how far detectShell walks up the process tree looking
+// for a shell, past wrappers such as sudo, mage or go run.
+const maxAncestors = 4
+
+// detectShell detects the current shell from the parent process, then $SHELL,
+// then the rc files that exist in the target home directory.
+func detectShell() Detection {
+	// Without a home directory the zero Paths has no rc files to find.
+	paths, _ := resolvePaths()
+	d := detectShellFrom("/proc", os.Getppid(), os.Getenv, paths)
+	_, err := exec.LookPath("starship")
+	d.Starship = err == nil
+	return d
+}
+
+// detectShellFrom is detectShell with its inputs made explicit: the proc
+// filesystem at procRoot, the parent process pid, the environment read
+// through getenv and the rc files in paths.
+func detectShellFrom(procRoot string, pid int, getenv func(string) string, paths Paths) Detection {
+	var d Detection
+	if shell := parentShell(procRoot, pid); shell != "" {
+		d.Shell, d.Source = shell, "parent process"
+	} else if shell := shellFromName(getenv("SHELL")); shell != "" {
+		d.Shell, d.Source = shell, "$SHELL"
+	} else if shell := shellFromRCFiles(paths); shell != "" {
+		d.Shell, d.Source = shell, "rc files"
+	}
+	return d
+}
+
+// String describes d for messages, e.g. "zsh (from $SHELL)".
+func (d Detection) String() string {
+	if d.Shell == "" {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s (from %s)", d.Shell, d.Source)
+}
+
+// shellFromName maps a shell binary name or path, such as /bin/zsh or -bash
+// for a login shell, onto a ShellTarget.
+func shellFromName(name string) ShellTarget {
+	name = strings.TrimPrefix(filepath.Base(name), "-")
+	switch name {
+	case "zsh":
+		return ShellZsh
+	case "bash":
+		return ShellBash
+	case "fish":
+		return ShellFish
+	case "pwsh":
+		return ShellPwsh
+	case "nu":
+		return ShellNu
+	}
+	return ""
+}
+
+// parentShell returns the nearest known shell among pid and its ancestors,
+// read from the proc filesystem at procRoot. It returns "" where that isn't
+// available.
+func parentShell(procRoot string, pid int) ShellTarget {
+	for i := 0; i < maxAncestors && pid > 1; i++ {
+		comm, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "comm"))
+		if err != nil {
+			return ""
+		}
+		if shell := shellFromName(strings.TrimSpace(string(comm))); shell != "" {
+			return shell
+		}
+		pid = parentPID(procRoot, pid)
+	}
+	return ""
+}
+
+// parentPID returns the parent of pid from <procRoot>/<pid>/stat, or 0.
+func parentPID(procRoot string, pid int) int {
+	stat, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0
+	}
+	// The command name in field 2 may contain spaces and parentheses, so
+	// the fields after it are found from the last ')'.
+	s := string(stat)
+	i := strings.LastIndexByte(s, ')')
+	if i < 0 {
+		return 0
+	}
+	fields := strings.Fields(s[i+1:])
+	if len(fields) < 2 {
+		return 0
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0
+	}
+	return ppid
+}
+
+// shellFromRCFiles returns the shell whose rc file in paths exists when it is
+// the only one that does. Several rc files are ambiguous and detect nothing.
+func shellFromRCFiles(paths Paths) ShellTarget {
+	var found ShellTarget
+	for _, shell := range []ShellTarget{ShellZsh, ShellBash, ShellFish, ShellPwsh, ShellNu} {
+		if _, err := os.Stat(paths.RCFile(string(shell))); err != nil {
+			continue
+		}
+		if found != "" {
+			return ""
+		}
+		found = shell
+	}
+	return found
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// fakeProc writes a proc filesystem holding a chain of processes, each the
+// parent of the one before it, the first with pid 100.
+func fakeProc(t *testing.T, comms ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for i, comm := range comms {
+		pid, ppid := 100+i, 100+i+1
+		if i == len(comms)-1 {
+			ppid = 1
+		}
+		dir := filepath.Join(root, strconv.Itoa(pid))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		// A command name with spaces and parentheses, as ps shows it.
+		stat := fmt.Sprintf("%d (%s) x) S %d %d\n", pid, comm, ppid, ppid)
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "comm"), []byte(comm+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDetectShellFrom(t *testing.T) {
+	home := t.TempDir()
+	paths := Paths{
+		ZshRC:       filepath.Join(home, ".zshrc"),
+		BashRC:      filepath.Join(home, ".bashrc"),
+		FishConfig:  filepath.Join(home, "fish", "config.fish"),
+		PwshProfile: filepath.Join(home, "powershell", "profile.ps1"),
+		NuConfig:    filepath.Join(home, "nushell", "config.nu"),
+	}
+	onlyFish, several := t.TempDir(), t.TempDir()
+	fishPaths, severalPaths := paths, paths
+	fishPaths.FishConfig = filepath.Join(onlyFish, "config.fish")
+	severalPaths.ZshRC = filepath.Join(several, ".zshrc")
+	severalPaths.BashRC = filepath.Join(several, ".bashrc")
+	for _, path := range []string{fishPaths.FishConfig, severalPaths.ZshRC, severalPaths.BashRC} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		proc       string
+		shell      string
+		paths      Paths
+		want       ShellTarget
+		wantSource string
+	}{
+		{"parent", fakeProc(t, "zsh"), "/bin/bash", paths, ShellZsh, "parent process"},
+		{"login shell", fakeProc(t, "-fish"), "", paths, ShellFish, "parent process"},
+		{"behind wrappers", fakeProc(t, "go", "mage", "sudo", "nu"), "/bin/zsh", paths, ShellNu, "parent process"},
+		{"too far up", fakeProc(t, "go", "mage", "sudo", "tmux", "zsh"), "/usr/bin/bash", paths, ShellBash, "$SHELL"},
+		{"no proc", filepath.Join(home, "missing"), "/opt/pwsh/pwsh", paths, ShellPwsh, "$SHELL"},
+		{"unknown $SHELL", fakeProc(t, "tmux"), "/bin/tcsh", fishPaths, ShellFish, "rc files"},
+		{"several rc files", fakeProc(t, "tmux"), "", severalPaths, "", ""},
+		{"nothing", fakeProc(t, "tmux"), "", paths, "", ""},
+	}
+	for _, tt := range tests {
+		getenv := func(name string) string {
+			if name == "SHELL" {
+				return tt.shell
+			}
+			return ""
+		}
+		d := detectShellFrom(tt.proc, 100, getenv, tt.paths)
+		if d.Shell != tt.want || d.Source != tt.wantSource {
+			t.Errorf("%s: got %s from %q, want %s from %q", tt.name, d.Shell, d.Source, tt.want, tt.wantSource)
+		}
+	}
+}
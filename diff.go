@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Unified diffs for --dry-run
+// ─────────────────────────────────────────────────────────────
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns a unified diff turning before into after, labelled with
+// fromName and toName. It returns "" if the contents are equal.
+func unifiedDiff(fromName, toName, before, after string) string {
+	if before == after {
+		return ""
+	}
+
+	a, b := splitLines(before), splitLines(after)
+	ops := diffLines(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change.
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Grow the hunk until there are more than 2*diffContext unchanged
+		// lines between two changes.
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+				continue
+			}
+			if i-end >= 2*diffContext {
+				break
+			}
+		}
+
+		from := max(start-diffContext, 0)
+		to := min(end+diffContext, len(ops))
+
+		aStart, bStart := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				aStart++
+			}
+			if op.kind != '-' {
+				bStart++
+			}
+		}
+		aLen, bLen := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, op := range ops[from:to] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// splitLines splits s into lines that keep their line endings.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a line diff of a and b. Common leading and trailing
+// lines are stripped first, so the quadratic LCS only ever sees the region
+// an rc edit actually touched.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffOp{' ', ma[i]})
+			i++
+			j++
+		case i < len(ma) && (j == len(mb) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', ma[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', mb[j]})
+			j++
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// printPlan writes every planned change as a unified diff against the file's
+// current contents.
+func printPlan(w io.Writer, changes []FileChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "Dry run: nothing would change.")
+		return
+	}
+
+	header := color.New(color.Bold)
+	removed := color.New(color.FgRed)
+	added := color.New(color.FgGreen)
+	hunk := color.New(color.FgCyan)
+
+	for _, c := range changes {
+		fromName, toName := c.Path, c.Path
+		if !c.Existed {
+			fromName = "/dev/null"
+		}
+		if c.Removed {
+			toName = "/dev/null"
+		}
+
+		diff := unifiedDiff(fromName, toName, string(c.Before), string(c.After))
+		for _, line := range splitLines(diff) {
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+				header.Fprintln(w, line)
+			case strings.HasPrefix(line, "@@"):
+				hunk.Fprintln(w, line)
+			case strings.HasPrefix(line, "-"):
+				removed.Fprintln(w, line)
+			case strings.HasPrefix(line, "+"):
+				added.Fprintln(w, line)
+			default:
+				fmt.Fprintln(w, line)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\nDry run: %d file(s) would change. Nothing was written.\n", len(changes))
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numbered returns the lines 1 to n, with the lines in replace swapped for
+// other text.
+func numbered(n int, replace map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		line, ok := replace[i]
+		if !ok {
+			line = strconv.Itoa(i)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{
+			name:   "equal",
+			before: "a\nb\n",
+			after:  "a\nb\n",
+			want:   "",
+		},
+		{
+			name:   "one change in the middle",
+			before: numbered(20, nil),
+			after:  numbered(20, map[int]string{10: "ten"}),
+			want: "--- a\n+++ b\n@@ -7,7 +7,7 @@\n" +
+				" 7\n 8\n 9\n-10\n+ten\n 11\n 12\n 13\n",
+		},
+		{
+			name:   "changes far apart",
+			before: numbered(20, nil),
+			after:  numbered(20, map[int]string{3: "three", 17: "seventeen"}),
+			want: "--- a\n+++ b\n" +
+				"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+				"@@ -14,7 +14,7 @@\n 14\n 15\n 16\n-17\n+seventeen\n 18\n 19\n 20\n",
+		},
+		{
+			name:   "pure add",
+			before: "",
+			after:  "a\nb\n",
+			want:   "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:   "pure delete",
+			before: "a\nb\n",
+			after:  "",
+			want:   "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:   "no trailing newline on either side",
+			before: "a\nb",
+			after:  "a\nc",
+			want: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n" +
+				"-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			name:   "trailing newline added",
+			before: "a\nb",
+			after:  "a\nb\n",
+			want:   "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := unifiedDiff("a", "b", tt.before, tt.after); got != tt.want {
+			t.Errorf("%s:\ngot:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestUnifiedDiffHunks checks where nearby changes share a hunk: up to twice
+// the context of unchanged lines between them, like diff -u.
+func TestUnifiedDiffHunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace map[int]string
+		want    []string
+	}{
+		{"five lines apart", map[int]string{5: "five", 11: "eleven"}, []string{"@@ -2,13 +2,13 @@"}},
+		{"six lines apart", map[int]string{5: "five", 12: "twelve"}, []string{"@@ -2,14 +2,14 @@"}},
+		{"seven lines apart", map[int]string{5: "five", 13: "thirteen"}, []string{"@@ -2,7 +2,7 @@", "@@ -10,7 +10,7 @@"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, line := range splitLines(unifiedDiff("a", "b", numbered(20, nil), numbered(20, tt.replace))) {
+			if strings.HasPrefix(line, "@@") {
+				got = append(got, strings.TrimSuffix(line, "\n"))
+			}
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: got hunks %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDiffLinesKeepsEveryLine(t *testing.T) {
+	a := splitLines("x\na\nb\nc\ny\n")
+	b := splitLines("x\nb\nd\ny\nz\n")
+	var gotA, gotB []string
+	for _, op := range diffLines(a, b) {
+		if op.kind != '+' {
+			gotA = append(gotA, op.line)
+		}
+		if op.kind != '-' {
+			gotB = append(gotB, op.line)
+		}
+	}
+	if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+		t.Errorf("the diff doesn't rebuild both sides: %q / %q", gotA, gotB)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Editing theme files
+// ─────────────────────────────────────────────────────────────
+
+// syntaxCheckers are the commands that check a theme file for a shell
+// without running it. The file's path is appended.
+var syntaxCheckers = map[string][]string{
+	themeSuffixes[ShellZsh]:  {"zsh", "-n"},
+	themeSuffixes[ShellBash]: {"bash", "-n"},
+	themeSuffixes[ShellFish]: {"fish", "--no-execute"},
+}
+
+// editorCommand returns the user's editor from $VISUAL or $EDITOR, split
+// into the program and its arguments, falling back to vi.
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// runEditor opens path in the user's editor and waits for it to exit.
+func runEditor(path string) error {
+	editor := editorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", editor[0], err)
+	}
+	return nil
+}
+
+// checkThemeFile checks the syntax of a theme file: specs, manifests and
+// starship configs are parsed, shell files are handed to their shell's
+// syntax check. It reports false if there is no way to check the file here.
+func checkThemeFile(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case strings.HasSuffix(path, specSuffix):
+		_, err := parseThemeSpec(data)
+		return true, err
+	case strings.HasSuffix(path, metaSuffix):
+		_, err := parseThemeMeta(data)
+		return true, err
+	case strings.HasSuffix(path, themeSuffixes[ShellStarship]):
+		var config map[string]any
+		_, err := toml.Decode(string(data), &config)
+		return true, err
+	}
+
+	for suffix, checker := range syntaxCheckers {
+		if !strings.HasSuffix(path, suffix) {
+			continue
+		}
+		if _, err := exec.LookPath(checker[0]); err != nil {
+			return false, nil
+		}
+		out, err := exec.Command(checker[0], append(checker[1:], path)...).CombinedOutput()
+		if err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				return true, errors.New(msg)
+			}
+			return true, fmt.Errorf("%s: %w", strings.Join(checker, " "), err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// editThemeFile opens a copy of path in the user's editor and checks it once
+// the editor exits. Only a copy that passes replaces path, so the file never
+// gets sourced broken: one that doesn't can be reopened or discarded, and an
+// error or abort along the way leaves path as it was. It reports whether the
+// file was changed.
+func editThemeFile(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	// The copy keeps the file's name, which tells checkThemeFile what
+	// kind of file it is.
+	dir, err := os.MkdirTemp("", "promptly-edit-*")
+	if err != nil {
+		return false, fmt.Errorf("failed to create a directory for editing: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	copyPath := filepath.Join(dir, filepath.Base(path))
+	if err := os.WriteFile(copyPath, original, 0600); err != nil {
+		return false, fmt.Errorf("failed to copy %s: %w", path, err)
+	}
+
+	for {
+		if err := runEditor(copyPath); err != nil {
+			return false, err
+		}
+		edited, err := os.ReadFile(copyPath)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Println("No changes.")
+			return false, nil
+		}
+
+		checked, err := checkThemeFile(copyPath)
+		if err == nil {
+			if err := writeFileAtomic(path, edited, info.Mode().Perm()); err != nil {
+				return false, fmt.Errorf("failed to save %s: %w", path, err)
+			}
+			if !checked {
+				color.Yellow("! Saved without a syntax check: there is no checker for %s here.", path)
+			} else {
+				color.Green("✓ %s checks out.", path)
+			}
+			return true, nil
+		}
+
+		color.Red("✗ %s has errors:", path)
+		fmt.Println(strings.ReplaceAll(err.Error(), copyPath, path))
+		prompt := promptui.Select{
+			Label: "What do you want to do",
+			Items: []string{"Reopen the file", "Discard the changes"},
+		}
+		i, _, err := prompt.Run()
+		if err != nil {
+			return false, err
+		}
+		if i == 1 {
+			fmt.Printf("Discarded the changes to %s.\n", path)
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useEditor makes editThemeFile's editor replace the file with content.
+func useEditor(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	replacement := filepath.Join(dir, "replacement")
+	if err := os.WriteFile(replacement, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	editor := filepath.Join(dir, "editor")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\ncat "+shQuote(replacement)+" > \"$1\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", editor)
+}
+
+func TestEditThemeFile(t *testing.T) {
+	spec := readString(t, "default"+specSuffix)
+	tests := []struct {
+		name, edited string
+		wantChanged  bool
+		wantErr      bool
+	}{
+		{"unchanged", spec, false, false},
+		{"valid", spec + "\n# edited\n", true, false},
+		// Without a terminal the prompt to reopen or discard fails.
+		{"broken", "prompt_char = [", false, true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "mine"+specSuffix)
+		if err := os.WriteFile(path, []byte(spec), 0640); err != nil {
+			t.Fatal(err)
+		}
+		useEditor(t, tt.edited)
+
+		changed, err := editThemeFile(path)
+		if changed != tt.wantChanged || (err != nil) != tt.wantErr {
+			t.Errorf("%s: got %v, %v", tt.name, changed, err)
+		}
+		want := spec
+		if tt.wantChanged {
+			want = tt.edited
+		}
+		if got := readString(t, path); got != want {
+			t.Errorf("%s: the file is now %q", tt.name, got)
+		}
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0640 {
+			t.Errorf("%s: lost its mode: %v %v", tt.name, info.Mode(), err)
+		}
+	}
+}
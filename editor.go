@@ -0,0 +1,522 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Custom theme editor
+// ─────────────────────────────────────────────────────────────
+
+// editorItem is an entry in one of the editor's menus. Preview is the prompt
+// as it would look with the entry picked, so moving through a menu shows
+// each choice live.
+type editorItem struct {
+	Label   string
+	Value   string
+	Preview string
+}
+
+// errDiscarded is returned by editThemeSpec when the changes are thrown away.
+var errDiscarded = errors.New("changes discarded")
+
+// editorOther is the entry that asks for a value not in the list.
+const editorOther = "Other…"
+
+var promptCharPresets = []string{"❯", "➜", "λ", "$", ">", "%", "→"}
+
+// iconPresets are the choices offered for each icon, Nerd Font glyphs first.
+var iconPresets = map[string][]string{
+	"git":       {"git", "\uf1d3"},
+	"github":    {"github", "gh", "\uf408", "\uf09b"},
+	"ahead":     {"\uf176", "⇡", "↑", "^"},
+	"behind":    {"\uf175", "⇣", "↓", "v"},
+	"diverged":  {"\uf7a5", "⇕", "↕", "<>"},
+	"staged":    {"+", "●", "✚"},
+	"unstaged":  {"!", "✱", "~"},
+	"untracked": {"?", "…", "%"},
+	"stashed":   {"$", "≡", "*"},
+}
+
+// sampleValues are what the git segments show in editor previews.
+var sampleValues = map[string]string{
+	segBranch:    "main",
+	segStaged:    "2",
+	segUnstaged:  "1",
+	segUntracked: "3",
+	segStashed:   "1",
+}
+
+// specSample renders spec as a preview sample: a prompt in a git repository
+// with something in every git segment.
+func specSample(spec ThemeSpec) PreviewSample {
+	var sample PreviewSample
+	var line []PreviewSegment
+	for _, seg := range spec.Segments {
+		var text string
+		switch seg.Type {
+		case segNewline:
+			sample.Lines = append(sample.Lines, line)
+			line = nil
+			continue
+		case segDir:
+			text = "~/projects/myapp"
+		case segText:
+			text = seg.Text
+		case segGitHost:
+			text = spec.Icons.GitHub
+		case segSync:
+			text = spec.Icons.Ahead + spec.SyncSeparator + "2"
+		case segStaged:
+			text = spec.Icons.Staged + sampleValues[seg.Type]
+		case segUnstaged:
+			text = spec.Icons.Unstaged + sampleValues[seg.Type]
+		case segUntracked:
+			text = spec.Icons.Untracked + sampleValues[seg.Type]
+		case segStashed:
+			text = spec.Icons.Stashed + sampleValues[seg.Type]
+		case segChar:
+			line = append(line, PreviewSegment{Text: seg.Prefix + spec.PromptChar, Color: seg.Color}, PreviewSegment{Text: " "})
+			continue
+		default:
+			text = sampleValues[seg.Type]
+		}
+		line = append(line, PreviewSegment{Text: seg.Prefix + text, Color: seg.Color})
+	}
+	sample.Lines = append(sample.Lines, line)
+	return sample
+}
+
+// specPreview renders spec the way the selector shows previews.
+func specPreview(spec ThemeSpec) string {
+	return renderPreview(specSample(spec), spec.Colors)
+}
+
+// cloneSpec copies spec so edits don't reach the theme it came from.
+func cloneSpec(spec ThemeSpec) ThemeSpec {
+	spec.Colors = maps.Clone(spec.Colors)
+	spec.Segments = append([]SpecSegment(nil), spec.Segments...)
+	return spec
+}
+
+// editorSelect shows a menu of items with the preview of the one under the
+// cursor below it.
+func editorSelect(label string, items []editorItem, cursor int) (int, error) {
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}:",
+		Active:   "▸ {{ .Label | cyan }}{{ if .Value }}  {{ .Value }}{{ end }}",
+		Inactive: "  {{ .Label | cyan }}{{ if .Value }}  {{ .Value }}{{ end }}",
+		Selected: "{{ .Label | cyan }}{{ if .Value }}  {{ .Value }}{{ end }}",
+		Details: `
+--------- Preview ---------
+{{ .Preview }}`,
+	}
+	prompt := promptui.Select{
+		Label:     label,
+		Items:     items,
+		Templates: templates,
+		Size:      len(items),
+		CursorPos: cursor,
+	}
+	i, _, err := prompt.Run()
+	return i, err
+}
+
+// editThemeSpec lets the user change the prompt character, segment colors,
+// icons and segment order of spec, previewing every choice. It returns the
+// edited spec, or errDiscarded if the user threw the changes away.
+func editThemeSpec(name string, spec ThemeSpec) (ThemeSpec, error) {
+	spec = cloneSpec(spec)
+	cursor := 0
+	for {
+		preview := specPreview(spec)
+		blankLine := "no"
+		if spec.BlankLine {
+			blankLine = "yes"
+		}
+		items := []editorItem{
+			{Label: "Prompt character", Value: spec.PromptChar, Preview: preview},
+			{Label: "Colors", Preview: preview},
+			{Label: "Icons", Preview: preview},
+			{Label: "Segment order", Preview: preview},
+			{Label: "Blank line before the prompt", Value: blankLine, Preview: preview},
+			{Label: "Save", Preview: preview},
+			{Label: "Discard changes", Preview: preview},
+		}
+
+		var err error
+		cursor, err = editorSelect(fmt.Sprintf("Edit '%s'", name), items, cursor)
+		if err != nil {
+			return ThemeSpec{}, err
+		}
+
+		switch items[cursor].Label {
+		case "Prompt character":
+			err = editPromptChar(&spec)
+		case "Colors":
+			err = editColors(&spec)
+		case "Icons":
+			err = editIcons(&spec)
+		case "Segment order":
+			err = editOrder(&spec)
+		case "Blank line before the prompt":
+			spec.BlankLine = !spec.BlankLine
+		case "Save":
+			if err := spec.validate(); err != nil {
+				color.Yellow("! The theme can't be saved like this: %v", err)
+				continue
+			}
+			return spec, nil
+		case "Discard changes":
+			return ThemeSpec{}, errDiscarded
+		}
+		if err != nil {
+			return ThemeSpec{}, err
+		}
+	}
+}
+
+// pickText offers presets for a piece of text, previewing each with set, and
+// asks for anything else. The current value is listed first.
+func pickText(label, current string, presets []string, preview func(string) string) (string, error) {
+	choices := []string{current}
+	for _, p := range presets {
+		if p != current {
+			choices = append(choices, p)
+		}
+	}
+
+	items := make([]editorItem, 0, len(choices)+1)
+	for _, c := range choices {
+		shown := c
+		if noIcons {
+			shown = textIcons(c)
+		}
+		items = append(items, editorItem{Label: `"` + shown + `"`, Preview: preview(c)})
+	}
+	items = append(items, editorItem{Label: editorOther, Preview: preview(current)})
+
+	i, err := editorSelect(label, items, 0)
+	if err != nil {
+		return "", err
+	}
+	if i < len(choices) {
+		return choices[i], nil
+	}
+
+	prompt := promptui.Prompt{Label: label, Default: current, AllowEdit: true}
+	return prompt.Run()
+}
+
+func editPromptChar(spec *ThemeSpec) error {
+	char, err := pickText("Prompt character", spec.PromptChar, promptCharPresets, func(c string) string {
+		s := cloneSpec(*spec)
+		s.PromptChar = c
+		return specPreview(s)
+	})
+	if err != nil {
+		return err
+	}
+	spec.PromptChar = char
+	return nil
+}
+
+// segmentLabel names a segment in the editor's menus.
+func segmentLabel(seg SpecSegment) string {
+	if seg.Type == segText {
+		return fmt.Sprintf("text %q", seg.Text)
+	}
+	return seg.Type
+}
+
+// colorSwatch shows a color value drawn in itself.
+func colorSwatch(spec ThemeSpec, name string) string {
+	value := name
+	if v, ok := spec.Colors[name]; ok {
+		value = v
+	}
+	return colorize(value, "■ "+name)
+}
+
+// editColors picks a segment, or the color of the prompt character after an
+// error, and then its color.
+func editColors(spec *ThemeSpec) error {
+	type target struct {
+		index int
+		error bool
+	}
+	var targets []target
+	var items []editorItem
+	preview := specPreview(*spec)
+	for i, seg := range spec.Segments {
+		if seg.Type == segNewline {
+			continue
+		}
+		targets = append(targets, target{index: i})
+		items = append(items, editorItem{Label: segmentLabel(seg), Value: colorSwatch(*spec, seg.Color), Preview: preview})
+		if seg.Type == segChar {
+			errColor := seg.ErrorColor
+			if errColor == "" {
+				errColor = seg.Color
+			}
+			targets = append(targets, target{index: i, error: true})
+			items = append(items, editorItem{Label: "char after an error", Value: colorSwatch(*spec, errColor), Preview: preview})
+		}
+	}
+
+	i, err := editorSelect("Pick a segment to color", items, 0)
+	if err != nil {
+		return err
+	}
+	t := targets[i]
+
+	set := func(s *ThemeSpec, c string) {
+		if t.error {
+			s.Segments[t.index].ErrorColor = c
+		} else {
+			s.Segments[t.index].Color = c
+		}
+	}
+
+	roles := make([]string, 0, len(spec.Colors))
+	for role := range spec.Colors {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	choices := append(roles, "black", "red", "green", "yellow", "blue", "magenta", "cyan", "white")
+
+	items = items[:0]
+	for _, c := range choices {
+		s := cloneSpec(*spec)
+		set(&s, c)
+		value := ""
+		if v, ok := spec.Colors[c]; ok {
+			value = v
+		}
+		items = append(items, editorItem{Label: colorSwatch(*spec, c), Value: value, Preview: specPreview(s)})
+	}
+	items = append(items, editorItem{Label: editorOther, Preview: preview})
+
+	i, err = editorSelect("Pick a color", items, 0)
+	if err != nil {
+		return err
+	}
+	if i < len(choices) {
+		set(spec, choices[i])
+		return nil
+	}
+
+	prompt := promptui.Prompt{
+		Label: "Color (#rrggbb, 0-255 or a basic color name)",
+		Validate: func(s string) error {
+			_, err := parseColor(s)
+			return err
+		},
+	}
+	c, err := prompt.Run()
+	if err != nil {
+		return err
+	}
+	set(spec, c)
+	return nil
+}
+
+// iconFields lists the icons of spec by name, in the order the editor shows
+// them.
+func iconFields(spec *ThemeSpec) []struct {
+	name  string
+	value *string
+} {
+	return []struct {
+		name  string
+		value *string
+	}{
+		{"git", &spec.Icons.Git},
+		{"github", &spec.Icons.GitHub},
+		{"ahead", &spec.Icons.Ahead},
+		{"behind", &spec.Icons.Behind},
+		{"diverged", &spec.Icons.Diverged},
+		{"staged", &spec.Icons.Staged},
+		{"unstaged", &spec.Icons.Unstaged},
+		{"untracked", &spec.Icons.Untracked},
+		{"stashed", &spec.Icons.Stashed},
+	}
+}
+
+func editIcons(spec *ThemeSpec) error {
+	fields := iconFields(spec)
+	preview := specPreview(*spec)
+	items := make([]editorItem, len(fields))
+	for i, f := range fields {
+		shown := *f.value
+		if noIcons {
+			shown = textIcons(shown)
+		}
+		items[i] = editorItem{Label: f.name, Value: shown, Preview: preview}
+	}
+
+	i, err := editorSelect("Pick an icon", items, 0)
+	if err != nil {
+		return err
+	}
+	field := fields[i]
+
+	icon, err := pickText(field.name+" icon", *field.value, iconPresets[field.name], func(icon string) string {
+		s := cloneSpec(*spec)
+		*iconFields(&s)[i].value = icon
+		return specPreview(s)
+	})
+	if err != nil {
+		return err
+	}
+	*field.value = icon
+	return nil
+}
+
+// moveSegment returns the segments with the one at from moved to to.
+func moveSegment(segments []SpecSegment, from, to int) []SpecSegment {
+	moved := append([]SpecSegment(nil), segments...)
+	seg := moved[from]
+	moved = append(moved[:from], moved[from+1:]...)
+	moved = append(moved[:to], append([]SpecSegment{seg}, moved[to:]...)...)
+	return moved
+}
+
+// editOrder picks a segment and a new position for it. Positions that would
+// break the theme, e.g. split up the git status segments, say why.
+func editOrder(spec *ThemeSpec) error {
+	preview := specPreview(*spec)
+	items := make([]editorItem, len(spec.Segments))
+	for i, seg := range spec.Segments {
+		items[i] = editorItem{Label: fmt.Sprintf("%2d. %s", i+1, segmentLabel(seg)), Preview: preview}
+	}
+	from, err := editorSelect("Pick a segment to move", items, 0)
+	if err != nil {
+		return err
+	}
+
+	orders := make([][]SpecSegment, len(spec.Segments))
+	for to := range spec.Segments {
+		s := cloneSpec(*spec)
+		s.Segments = moveSegment(spec.Segments, from, to)
+		orders[to] = s.Segments
+
+		var neighbours []string
+		if to > 0 {
+			neighbours = append(neighbours, "after "+segmentLabel(s.Segments[to-1]))
+		}
+		if to < len(s.Segments)-1 {
+			neighbours = append(neighbours, "before "+segmentLabel(s.Segments[to+1]))
+		}
+		items[to] = editorItem{Label: fmt.Sprintf("%2d. %s", to+1, strings.Join(neighbours, ", ")), Preview: specPreview(s)}
+		if err := s.validate(); err != nil {
+			items[to].Value = color.YellowString("(%v)", err)
+		}
+	}
+
+	to, err := editorSelect(fmt.Sprintf("Move %s to", segmentLabel(spec.Segments[from])), items, from)
+	if err != nil {
+		return err
+	}
+	s := cloneSpec(*spec)
+	s.Segments = orders[to]
+	if err := s.validate(); err != nil {
+		color.Yellow("! Can't move it there: %v", err)
+		return nil
+	}
+	spec.Segments = s.Segments
+	return nil
+}
+
+// editCustomTheme opens theme in the editor. Saving gives it the edited spec,
+// recompiled contents and a preview that matches.
+func editCustomTheme(theme Theme) (Theme, error) {
+	if theme.Spec == nil {
+		return Theme{}, fmt.Errorf("theme %q has no theme spec to edit", theme.Name)
+	}
+	spec, err := editThemeSpec(theme.Name, *theme.Spec)
+	if err != nil {
+		return Theme{}, err
+	}
+	return withEditedSpec(theme, spec), nil
+}
+
+// withEditedSpec returns theme with spec in place of its own. Its compiled
+// variants are compiled again; hand-written ones are kept.
+func withEditedSpec(theme Theme, spec ThemeSpec) Theme {
+	theme.Spec = &spec
+	if theme.Meta.Name == "" {
+		theme.Meta.Name = theme.Name
+		theme.Meta.Description = theme.Description
+	}
+	contents := make(map[ShellTarget]string, len(theme.Contents))
+	compiled := compileTheme(theme.Name, spec)
+	for shell, content := range theme.Contents {
+		if isGenerated(content) {
+			content = compiled[shell]
+		}
+		contents[shell] = content
+	}
+	theme.Contents = contents
+	theme.Meta.Preview = specSample(spec)
+	theme.Preview = specPreview(spec)
+	theme.SaveSpec = true
+	return theme
+}
+
+// saveCustomTheme writes the spec and manifest of an edited custom theme,
+// along with its compiled variant for shell and any other compiled variant
+// already in the config directory.
+func saveCustomTheme(theme Theme, shell ShellTarget, dryRun bool) error {
+	paths, err := resolvePaths()
+	if err != nil {
+		return err
+	}
+
+	err = applyThemeChange(dryRun, func(tx *Transaction) error {
+		if err := writeCustomSpec(tx, theme); err != nil {
+			return err
+		}
+		if err := writeCustomMeta(tx, theme); err != nil {
+			return err
+		}
+		_, err := writeCompiledVariants(tx, paths, theme, shell)
+		return err
+	})
+	if err != nil || dryRun {
+		return err
+	}
+	color.Green("✓ Saved custom theme '%s'.", theme.Name)
+	return nil
+}
+
+// writeCompiledVariants rewrites the compiled variants of a custom theme that
+// are in the config directory, and writes the one for shell if shell is set.
+// Hand-written variants are left as they are. It returns the files written.
+func writeCompiledVariants(tx *Transaction, paths Paths, theme Theme, shell ShellTarget) ([]string, error) {
+	var written []string
+	for _, target := range allShellTargets {
+		content, ok := theme.Contents[target]
+		if !ok || !isGenerated(content) {
+			continue
+		}
+		path := filepath.Join(paths.Config, theme.Name+themeSuffixes[target])
+		if _, err := os.Stat(path); target != shell && err != nil {
+			continue
+		}
+		if err := tx.WriteFile(path, []byte(content), 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
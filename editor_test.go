@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// texts returns the text of each segment, joined.
+func texts(segments []SpecSegment) string {
+	var b strings.Builder
+	for _, seg := range segments {
+		b.WriteString(seg.Text)
+	}
+	return b.String()
+}
+
+func TestMoveSegment(t *testing.T) {
+	segments := []SpecSegment{{Text: "a"}, {Text: "b"}, {Text: "c"}, {Text: "d"}}
+	tests := []struct {
+		from, to int
+		want     string
+	}{
+		{0, 0, "abcd"},
+		{0, 3, "bcda"},
+		{3, 0, "dabc"},
+		{1, 2, "acbd"},
+		{2, 1, "acbd"},
+		{3, 3, "abcd"},
+	}
+	for _, tt := range tests {
+		if got := texts(moveSegment(segments, tt.from, tt.to)); got != tt.want {
+			t.Errorf("%d to %d: got %s, want %s", tt.from, tt.to, got, tt.want)
+		}
+		if got := texts(segments); got != "abcd" {
+			t.Fatalf("%d to %d changed the segments it was given to %s", tt.from, tt.to, got)
+		}
+	}
+}
+
+func TestCloneSpec(t *testing.T) {
+	spec := builtinSpecs(t)["default"]
+	dir, first := spec.Colors["dir"], spec.Segments[0]
+
+	clone := cloneSpec(spec)
+	clone.Colors["dir"] = "#123456"
+	clone.Segments[0].Color = "red"
+	clone.Segments = moveSegment(clone.Segments, 0, len(clone.Segments)-1)
+
+	if spec.Colors["dir"] != dir || spec.Segments[0] != first {
+		t.Errorf("editing the clone changed the spec: %q %+v", spec.Colors["dir"], spec.Segments[0])
+	}
+}
+
+func TestWithEditedSpec(t *testing.T) {
+	spec := builtinSpecs(t)["default"]
+	const handWritten = "function fish_prompt; echo '> '; end\n"
+	contents := compileTheme("mine", spec)
+	contents[ShellFish] = handWritten
+	delete(contents, ShellNu)
+	theme := Theme{Name: "mine", IsCustom: true, Contents: contents, Spec: &spec}
+
+	edited := cloneSpec(spec)
+	edited.PromptChar = "%"
+	got := withEditedSpec(theme, edited)
+
+	want := compileTheme("mine", edited)
+	for shell, content := range got.Contents {
+		switch {
+		case shell == ShellFish && content != handWritten:
+			t.Errorf("the hand-written fish variant was replaced")
+		case shell != ShellFish && content != want[shell]:
+			t.Errorf("%s wasn't compiled from the edited spec", shell)
+		}
+	}
+	if _, ok := got.Contents[ShellNu]; ok {
+		t.Errorf("a variant the theme doesn't have was added")
+	}
+	if !got.SaveSpec || got.Spec.PromptChar != "%" || theme.Spec.PromptChar == "%" {
+		t.Errorf("the edited spec isn't the theme's own: %+v", got)
+	}
+}
+
+func TestWriteCompiledVariants(t *testing.T) {
+	paths := Paths{Config: t.TempDir()}
+	spec := builtinSpecs(t)["default"]
+	contents := compileTheme("mine", spec)
+	contents[ShellFish] = "function fish_prompt; echo '> '; end\n"
+	theme := Theme{Name: "mine", Contents: contents, Spec: &spec}
+
+	// The bash variant was installed before; zsh is being installed now.
+	for _, file := range []string{"mine.promptly.bash", "mine.promptly.fish"} {
+		if err := os.WriteFile(filepath.Join(paths.Config, file), []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var written []string
+	err := runTransaction(func(tx *Transaction) error {
+		var err error
+		written, err = writeCompiledVariants(tx, paths, theme, ShellZsh)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, path := range written {
+		written[i] = filepath.Base(path)
+	}
+	if got := strings.Join(written, " "); got != "mine.promptly.zsh mine.promptly.bash" {
+		t.Errorf("wrote %s", got)
+	}
+	if got := readString(t, filepath.Join(paths.Config, "mine.promptly.fish")); got != "old\n" {
+		t.Errorf("the hand-written fish variant was overwritten with %q", got)
+	}
+}
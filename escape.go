@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Escaping git values
+// ─────────────────────────────────────────────────────────────
+
+// Branch and tag names come from whatever repository the prompt is drawn in,
+// so they are untrusted: `git checkout -b '$(touch${IFS}pwned)'` is a valid
+// branch. zsh with prompt_subst and bash expand $, ` and \ in the prompt
+// string every time they draw it, so a name pasted into the prompt as is
+// would run there.
+
+var (
+	// zshPromptEscaper makes text literal in a zsh prompt with prompt_subst:
+	// the prompt is expanded like a double-quoted string, then for % escapes.
+	zshPromptEscaper = strings.NewReplacer(`\`, `\\`, `$`, `\$`, "`", "\\`", `%`, `%%`)
+
+	// bashPromptEscaper makes text literal in a bash prompt. Bash decodes its
+	// backslash escapes first, turning \\ into \, and then expands the result
+	// like a double-quoted string. \$ can't be used, since it decodes to #
+	// for root.
+	bashPromptEscaper = strings.NewReplacer(`\`, `\\\\`, `$`, `\\$`, "`", "\\\\`")
+)
+
+// sanitizeGitValue drops control characters from a value read from git, so
+// it can't move the cursor or change the terminal's colors.
+func sanitizeGitValue(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// escapePromptValue makes a git value, such as a branch or tag name, safe to
+// use as literal text in the prompt string of shell. fish, PowerShell and Nushell
+// print their prompt from a function and never expand its output again, so
+// only control characters are dropped there.
+func escapePromptValue(shell ShellTarget, s string) string {
+	s = sanitizeGitValue(s)
+	switch shell {
+	case ShellZsh:
+		return zshPromptEscaper.Replace(s)
+	case ShellBash:
+		return bashPromptEscaper.Replace(s)
+	}
+	return s
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// maliciousBranch is a valid git branch name that runs a command when a
+// shell expands it. Branch names can't contain spaces, hence ${IFS}.
+const maliciousBranch = "$(touch${IFS}pwned)`touch${IFS}pwned`"
+
+// promptValues are names that break a prompt string unless escaped.
+var promptValues = []string{
+	maliciousBranch,
+	`\$(touch${IFS}pwned)`,
+	`fix/100%-done`,
+	`%F{red}red`,
+	`a\\b\`,
+	`!!`,
+	`"quoted"`,
+}
+
+// requireShell skips the test when shell isn't installed.
+func requireShell(t *testing.T, shell string) {
+	t.Helper()
+	if _, err := exec.LookPath(shell); err != nil {
+		t.Skipf("%s is not installed", shell)
+	}
+}
+
+// expandPrompt has shell draw prompt in dir and returns the text it shows.
+func expandPrompt(t *testing.T, shell, dir, setup, prompt string) string {
+	t.Helper()
+	script := map[string]string{
+		"bash": setup + `; printf '%s' "${PS1@P}"`,
+		"zsh":  setup + `; setopt prompt_subst; print -rn -- ${(%%)PROMPT}`,
+	}[shell]
+	cmd := exec.Command(shell, "-c", script)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "PROMPT_VALUE="+prompt)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %v\n%s", shell, err, out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pwned")); err == nil {
+		t.Fatalf("%s ran a command from the prompt %q", shell, prompt)
+	}
+	return string(out)
+}
+
+func TestEscapePromptValue(t *testing.T) {
+	setups := map[ShellTarget]string{
+		ShellBash: `PS1=$PROMPT_VALUE`,
+		ShellZsh:  `PROMPT=$PROMPT_VALUE`,
+	}
+	for shell, setup := range setups {
+		t.Run(string(shell), func(t *testing.T) {
+			requireShell(t, string(shell))
+			for _, value := range promptValues {
+				got := expandPrompt(t, string(shell), t.TempDir(), setup, escapePromptValue(shell, value))
+				if got != value {
+					t.Errorf("%q is shown as %q", value, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeGitValue(t *testing.T) {
+	if got := sanitizeGitValue("main\x1b[2J\x07\u009b"); got != "main[2J" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// TestCompiledThemeMaliciousBranch draws each built-in theme's zsh and bash
+// prompts in a repository whose branch runs a command when expanded.
+func TestCompiledThemeMaliciousBranch(t *testing.T) {
+	requireShell(t, "git")
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "symbolic-ref", "HEAD", "refs/heads/"+maliciousBranch)
+
+	for name, spec := range builtinSpecs(t) {
+		contents := compileTheme(name, spec)
+		for _, shell := range []ShellTarget{ShellBash, ShellZsh} {
+			t.Run(name+"/"+string(shell), func(t *testing.T) {
+				requireShell(t, string(shell))
+				file := filepath.Join(t.TempDir(), name+themeSuffixes[shell])
+				if err := os.WriteFile(file, []byte(contents[shell]), 0644); err != nil {
+					t.Fatal(err)
+				}
+				got := expandPrompt(t, string(shell), repo, ". "+shQuote(file)+"; __promptly_build_prompt", "")
+				if !strings.Contains(got, maliciousBranch) {
+					t.Errorf("the prompt doesn't show the branch name as is:\n%s", got)
+				}
+			})
+		}
+	}
+}
+
+func TestLintGitValues(t *testing.T) {
+	const gitInfo = "branch=$(git symbolic-ref --short HEAD 2>/dev/null)\nshown=\"($branch)\"\n"
+	tests := []struct {
+		shell  ShellTarget
+		prompt string
+		want   string // severity of the diagnostic, or "" for none
+	}{
+		{ShellZsh, `PROMPT="%F{blue}${branch}%f "`, sevError},
+		{ShellZsh, `PROMPT="%F{blue}$shown%f "`, sevError},
+		{ShellZsh, `PROMPT='%F{blue}${branch}%f '`, sevWarning},
+		{ShellZsh, `PROMPT='%F{blue}${branch//\%/%%}%f '`, ""},
+		{ShellZsh, "p=\"${vcs_info_msg_0_}\"\nPROMPT=$p", sevError},
+		{ShellBash, `PS1="\w $branch \$ "`, sevError},
+		{ShellBash, "p+=\"$shown\"\nPS1=$p", sevError},
+		{ShellBash, `PS1='\w $branch \$ '`, ""},
+		{ShellBash, `PS1="\w "'$branch'" \$ "`, ""},
+		{ShellZsh, "precmd() {\n  PROMPT=\"%F{cyan}$(git symbolic-ref --short HEAD)%f \"\n}", sevError},
+		{ShellZsh, "PROMPT=`git symbolic-ref --short HEAD`", sevError},
+		{ShellBash, `PS1="\w $(command git branch --show-current) \$ "`, sevError},
+		{ShellBash, `PS1="\w \$(git branch --show-current) \$ "`, ""},
+		{ShellBash, `PS1="it's \w"'$(git branch --show-current)'`, ""},
+	}
+	for _, tt := range tests {
+		diags := lintGitValues("theme", gitInfo+tt.prompt+"\n", tt.shell)
+		switch {
+		case tt.want == "" && len(diags) != 0:
+			t.Errorf("%s %s: unexpected %v", tt.shell, tt.prompt, diags)
+		case tt.want != "" && (len(diags) != 1 || diags[0].Severity != tt.want):
+			t.Errorf("%s %s: got %v, want one %s", tt.shell, tt.prompt, diags, tt.want)
+		}
+	}
+}
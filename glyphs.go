@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"os/exec"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Nerd Font fallback
+// ─────────────────────────────────────────────────────────────
+
+// noIcons is set by --no-icons: Nerd Font glyphs are replaced with plain
+// text when themes are installed and previewed.
+var noIcons bool
+
+// addNoIconsFlag registers --no-icons on the commands that render previews
+// or install themes.
+func addNoIconsFlag(fs *flag.FlagSet) {
+	fs.BoolVar(&noIcons, "no-icons", false, "replace Nerd Font glyphs with plain text")
+}
+
+// glyphFallbacks maps the Nerd Font glyphs promptly's themes use onto text
+// that any font can show.
+var glyphFallbacks = map[rune]string{
+	'\uf1d3':     "git",    // git logo
+	'\uf408':     "github", // octoface
+	'\uf113':     "github", // github alt
+	'\uf09b':     "github", // github
+	'\ue725':     "⎇",      // git branch
+	'\uf418':     "⎇",      // git branch
+	'\ue0a0':     "⎇",      // powerline branch
+	'\uf176':     "↑",      // arrow up
+	'\uf175':     "↓",      // arrow down
+	'\uf7a5':     "↕",      // arrows up/down
+	'\uf062':     "↑",      // arrow up
+	'\uf063':     "↓",      // arrow down
+	'\uf00c':     "✓",      // check
+	'\uf00d':     "✗",      // cross
+	'\uf071':     "!",      // warning
+	'\uf023':     "ro",     // lock
+	'\U000f033e': "ro",     // lock
+}
+
+// installedContent returns theme's variant for shell as it is installed.
+// --no-icons only replaces glyphs in what is installed, never in the theme
+// itself: a variant compiled from the spec is compiled again with text
+// icons, and a hand-written one has its glyphs replaced. A custom theme
+// whose variant changes is installed as a copy instead of a stub that
+// sources the one in the config directory.
+func installedContent(theme Theme, shell ShellTarget) string {
+	content := theme.Contents[shell]
+	switch {
+	case !noIcons:
+		return content
+	case theme.Spec != nil && isGenerated(content):
+		return compileTheme(theme.Name, theme.Spec.withTextIcons())[shell]
+	}
+	return textIcons(content)
+}
+
+// isNerdGlyph reports whether r lies in the private use areas Nerd Fonts
+// put their glyphs in.
+func isNerdGlyph(r rune) bool {
+	return unicode.In(r, unicode.Co)
+}
+
+// textIcons replaces the Nerd Font glyphs in s with their fallbacks. Glyphs
+// without one are dropped.
+func textIcons(s string) string {
+	if !strings.ContainsFunc(s, isNerdGlyph) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case glyphFallbacks[r] != "":
+			b.WriteString(glyphFallbacks[r])
+		case !isNerdGlyph(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// withTextIcons returns spec with its glyphs replaced by text. Lines of
+// starship's extra config that use a glyph are dropped instead, so starship
+// falls back to its own defaults for them.
+func (s ThemeSpec) withTextIcons() ThemeSpec {
+	s.PromptChar = textIcons(s.PromptChar)
+	s.SyncSeparator = textIcons(s.SyncSeparator)
+	s.Icons = SpecIcons{
+		Git:       textIcons(s.Icons.Git),
+		GitHub:    textIcons(s.Icons.GitHub),
+		Ahead:     textIcons(s.Icons.Ahead),
+		Behind:    textIcons(s.Icons.Behind),
+		Diverged:  textIcons(s.Icons.Diverged),
+		Staged:    textIcons(s.Icons.Staged),
+		Unstaged:  textIcons(s.Icons.Unstaged),
+		Untracked: textIcons(s.Icons.Untracked),
+		Stashed:   textIcons(s.Icons.Stashed),
+	}
+
+	segments := make([]SpecSegment, len(s.Segments))
+	for i, seg := range s.Segments {
+		seg.Text = textIcons(seg.Text)
+		seg.Prefix = textIcons(seg.Prefix)
+		segments[i] = seg
+	}
+	s.Segments = segments
+
+	var extra []string
+	for _, line := range strings.Split(s.Starship.Extra, "\n") {
+		if !strings.ContainsFunc(line, isNerdGlyph) {
+			extra = append(extra, line)
+		}
+	}
+	s.Starship.Extra = strings.Join(extra, "\n")
+	return s
+}
+
+// hasNerdFont reports whether fontconfig knows a Nerd Font. ok is false
+// when fc-list isn't available to ask.
+func hasNerdFont() (found, ok bool) {
+	out, err := exec.Command("fc-list", ":", "family").Output()
+	if err != nil {
+		return false, false
+	}
+	families := strings.ToLower(string(out))
+	return strings.Contains(families, "nerd font") || strings.Contains(families, "nerdfont"), true
+}
+
+// warnMissingNerdFont warns that theme's glyphs will show up as boxes when
+// no Nerd Font is installed.
+func warnMissingNerdFont(theme Theme) {
+	if noIcons || theme.Meta.Font != "nerd" {
+		return
+	}
+	if found, ok := hasNerdFont(); ok && !found {
+		color.Yellow("! %s uses Nerd Font glyphs, but fc-list found no Nerd Font. Install one from https://www.nerdfonts.com/ or use --no-icons.", theme.Name)
+	}
+}
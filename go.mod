@@ -3,6 +3,7 @@ module promptly
 go 1.21
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/fatih/color v1.15.0
 	github.com/magefile/mage v1.15.0
 	github.com/manifoldco/promptui v0.9.0
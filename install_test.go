@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInstallNoIconsKeepsCustomTheme checks that --no-icons replaces glyphs
+// in the installed file only, not in the custom theme it was installed from.
+func TestInstallNoIconsKeepsCustomTheme(t *testing.T) {
+	paths := useHome(t)
+	noIcons = true
+	t.Cleanup(func() { noIcons = false })
+
+	const custom = "PROMPT=\"%~ \ue0a0 \"\n"
+	theme := Theme{Name: "mine", IsCustom: true, Contents: map[ShellTarget]string{ShellZsh: custom}}
+	if err := runTransaction(func(tx *Transaction) error { return installZsh(tx, theme) }); err != nil {
+		t.Fatal(err)
+	}
+	if got := readString(t, filepath.Join(paths.Config, "mine.promptly.zsh")); got != custom {
+		t.Errorf("the custom theme was changed to %q", got)
+	}
+	if got := readString(t, paths.ZshTheme); got != textIcons(custom) || strings.Contains(got, "source") {
+		t.Errorf("installed %q, want a copy without glyphs", got)
+	}
+}
+
+// TestCreateCustomThemeNoIconsKeepsGlyphs checks that a custom theme made
+// from a spec under --no-icons keeps its glyphs in the config directory.
+func TestCreateCustomThemeNoIconsKeepsGlyphs(t *testing.T) {
+	paths := useHome(t)
+	noIcons = true
+	t.Cleanup(func() { noIcons = false })
+
+	themes, err := loadThemes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := findTheme(themes, "icons")
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom, err := createCustomTheme(base, ShellZsh, "mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.ContainsFunc(custom.Contents[ShellZsh], isNerdGlyph) {
+		t.Fatalf("the custom theme lost its glyphs:\n%s", custom.Contents[ShellZsh])
+	}
+	if err := installTheme(custom, ShellZsh, InstallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"mine.promptly.zsh", "mine" + specSuffix} {
+		if got := readString(t, filepath.Join(paths.Config, file)); !strings.ContainsFunc(got, isNerdGlyph) {
+			t.Errorf("%s lost its glyphs:\n%s", file, got)
+		}
+	}
+	if got := readString(t, paths.ZshTheme); strings.ContainsFunc(got, isNerdGlyph) || !isGenerated(got) {
+		t.Errorf("the installed theme isn't compiled with text icons:\n%s", got)
+	}
+}
@@ -0,0 +1,600 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Theme linter
+// ─────────────────────────────────────────────────────────────
+
+// Diagnostic severities. Only errors make lint fail.
+const (
+	sevError   = "error"
+	sevWarning = "warning"
+)
+
+// Diagnostic is a problem lint found in a theme file. Line is 1-based, or 0
+// when the problem isn't tied to a line.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Severity string
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", d.File, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Severity, d.Message)
+}
+
+// lintTheme checks every zsh, bash, fish and starship variant of theme. Files are
+// named after where they are installed from: the config directory for
+// custom themes, the theme name for built-in ones.
+func lintTheme(theme Theme, configDir string) []Diagnostic {
+	var diags []Diagnostic
+	for _, shell := range []ShellTarget{ShellZsh, ShellBash, ShellFish, ShellStarship} {
+		content, ok := theme.Contents[shell]
+		if !ok {
+			continue
+		}
+		file := theme.Name + themeSuffixes[shell]
+		if theme.IsCustom {
+			file = filepath.Join(configDir, file)
+		}
+		switch shell {
+		case ShellZsh:
+			diags = append(diags, lintZsh(file, content)...)
+		case ShellBash:
+			diags = append(diags, lintBash(file, content)...)
+		case ShellFish:
+			diags = append(diags, lintFish(file, content)...)
+		case ShellStarship:
+			diags = append(diags, lintStarship(file, content)...)
+		}
+	}
+	return diags
+}
+
+// lintConfigFiles parses the specs and manifests in the config directory.
+// A broken one is skipped when custom themes are loaded, so this is where
+// its error shows up.
+func lintConfigFiles(configDir string) ([]Diagnostic, error) {
+	entries, err := os.ReadDir(configDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var diags []Diagnostic
+	for _, entry := range entries {
+		name := entry.Name()
+		var parse func([]byte) error
+		switch {
+		case strings.HasSuffix(name, specSuffix):
+			parse = func(data []byte) error { _, err := parseThemeSpec(data); return err }
+		case strings.HasSuffix(name, metaSuffix):
+			parse = func(data []byte) error { _, err := parseThemeMeta(data); return err }
+		default:
+			continue
+		}
+		path := filepath.Join(configDir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := parse(data); err != nil {
+			diags = append(diags, tomlDiagnostic(path, err))
+		}
+	}
+	return diags, nil
+}
+
+// tomlDiagnostic turns a TOML decoding or validation error into a
+// diagnostic, on the line the decoder points at if it does.
+func tomlDiagnostic(file string, err error) Diagnostic {
+	var perr toml.ParseError
+	if errors.As(err, &perr) {
+		return Diagnostic{File: file, Line: perr.Position.Line, Severity: sevError, Message: perr.Message}
+	}
+	return Diagnostic{File: file, Severity: sevError, Message: err.Error()}
+}
+
+// lineAt returns the 1-based line of offset in content.
+func lineAt(content string, offset int) int {
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+// syntaxPatterns pick the line number and message out of a syntax checker's
+// complaints: "file:3: parse error" from zsh, "file: line 3: ..." from bash,
+// "file (line 3): ..." from fish.
+var syntaxPatterns = map[ShellTarget]*regexp.Regexp{
+	ShellZsh:  regexp.MustCompile(`:(\d+): (.+)$`),
+	ShellBash: regexp.MustCompile(`: line (\d+): (.+)$`),
+	ShellFish: regexp.MustCompile(`\(line (\d+)\): (.+)$`),
+}
+
+// syntaxCheck runs the shell's syntax check on content and reports what it
+// complains about. Nothing is reported when the shell isn't installed.
+func syntaxCheck(file, content string, shell ShellTarget) []Diagnostic {
+	checker := syntaxCheckers[themeSuffixes[shell]]
+	if _, err := exec.LookPath(checker[0]); err != nil {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp("", "promptly-lint-*"+themeSuffixes[shell])
+	if err != nil {
+		return []Diagnostic{{File: file, Severity: sevError, Message: err.Error()}}
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return []Diagnostic{{File: file, Severity: sevError, Message: err.Error()}}
+	}
+
+	out, err := exec.Command(checker[0], append(checker[1:], tmp.Name())...).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	var diags []Diagnostic
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		d := Diagnostic{File: file, Severity: sevError, Message: strings.TrimSpace(line)}
+		if m := syntaxPatterns[shell].FindStringSubmatch(line); m != nil {
+			d.Line, _ = strconv.Atoi(m[1])
+			d.Message = m[2]
+		}
+		if d.Message != "" {
+			diags = append(diags, d)
+		}
+	}
+	if len(diags) == 0 {
+		diags = append(diags, Diagnostic{File: file, Severity: sevError, Message: fmt.Sprintf("%s failed: %v", strings.Join(checker, " "), err)})
+	}
+	return diags
+}
+
+var (
+	zshPromptSubst = regexp.MustCompile(`(?i)^\s*(setopt\s+.*\bprompt_?subst\b|set\s+-o\s+prompt_?subst\b)`)
+	zshPromptSet   = regexp.MustCompile(`^\s*(export\s+)?(PROMPT|PS1|RPROMPT|RPS1)\+?=`)
+	zshPrecmdDef   = regexp.MustCompile(`^\s*(function\s+precmd\b|precmd\s*\(\s*\))`)
+	zshPrecmdSet   = regexp.MustCompile(`^\s*precmd_functions=`)
+)
+
+// lintZsh checks a zsh theme: its syntax, that prompt_subst is set, that
+// every %F color is closed with %f, that it hooks into precmd without
+// replacing the hooks of other plugins and that git values can't get into
+// the prompt unescaped.
+func lintZsh(file, content string) []Diagnostic {
+	diags := syntaxCheck(file, content, ShellZsh)
+	diags = append(diags, lintGitValues(file, content, ShellZsh)...)
+
+	lines := strings.Split(content, "\n")
+	setsPrompt, hasSubst := 0, false
+	open := 0
+	for i, line := range lines {
+		n := i + 1
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if zshPromptSubst.MatchString(line) {
+			hasSubst = true
+		}
+		if setsPrompt == 0 && zshPromptSet.MatchString(line) {
+			setsPrompt = n
+		}
+		if zshPrecmdDef.MatchString(line) {
+			diags = append(diags, Diagnostic{File: file, Line: n, Severity: sevWarning,
+				Message: "defining precmd() replaces the precmd hooks of other plugins; use add-zsh-hook precmd <function>"})
+		}
+		if zshPrecmdSet.MatchString(line) {
+			diags = append(diags, Diagnostic{File: file, Line: n, Severity: sevWarning,
+				Message: "assigning precmd_functions drops the hooks of other plugins; use add-zsh-hook precmd <function>"})
+		}
+
+		// %F{color} starts a color and %f ends it; %% is a literal %.
+		for j := 0; j < len(line)-1; j++ {
+			if line[j] != '%' {
+				continue
+			}
+			switch line[j+1] {
+			case '%':
+			case 'F':
+				if open == 0 {
+					open = n
+				}
+			case 'f':
+				if open == 0 {
+					diags = append(diags, Diagnostic{File: file, Line: n, Severity: sevWarning, Message: "%f without a %F color to end"})
+				}
+				open = 0
+			}
+			j++
+		}
+	}
+	if open != 0 {
+		diags = append(diags, Diagnostic{File: file, Line: open, Severity: sevError,
+			Message: "%F color is never ended with %f, so it bleeds into the command line"})
+	}
+	if setsPrompt != 0 && !hasSubst {
+		diags = append(diags, Diagnostic{File: file, Line: setsPrompt, Severity: sevError,
+			Message: "setopt prompt_subst is missing, so variables and $(...) in the prompt are shown as is"})
+	}
+	sortDiagnostics(diags)
+	return diags
+}
+
+// lintBash checks the syntax of a bash theme and that git values can't get
+// into the prompt unescaped.
+func lintBash(file, content string) []Diagnostic {
+	diags := syntaxCheck(file, content, ShellBash)
+	diags = append(diags, lintGitValues(file, content, ShellBash)...)
+	sortDiagnostics(diags)
+	return diags
+}
+
+var (
+	shAssign     = regexp.MustCompile(`^\s*(?:(?:local|typeset|declare|export|readonly)\s+(?:-\w+\s+)*)?(\w+)\+?=(.*)$`)
+	shGitCommand = regexp.MustCompile("(\\$\\(|`)\\s*(command\\s+)?git\\s")
+	shPromptCopy = regexp.MustCompile(`^\s*(?:export\s+)?(?:PROMPT|PS1|RPROMPT|RPS1)=["']?\$\{?(\w+)\}?["']?\s*$`)
+	shGitNames   = regexp.MustCompile(`^(__promptly_git_\w+|vcs_info_msg_\d+_)$`)
+	shName       = regexp.MustCompile(`^\w+`)
+)
+
+// shRef is a $name or ${name...} in the value of a zsh or bash assignment.
+type shRef struct {
+	name   string
+	text   string // the whole reference, e.g. ${branch//\%/%%}
+	quoted bool   // in single quotes, so it is only expanded when the prompt is drawn
+}
+
+// shRefs finds the variable references in value, the shell code after the =
+// of an assignment.
+func shRefs(value string) []shRef {
+	var refs []shRef
+	single, double := false, false
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case single:
+			if c == '\'' {
+				single = false
+				continue
+			}
+		case c == '\\':
+			i++
+			continue
+		case c == '\'' && !double:
+			single = true
+			continue
+		case c == '"':
+			double = !double
+			continue
+		}
+		if c != '$' || i+1 == len(value) {
+			continue
+		}
+
+		ref := shRef{quoted: single}
+		if value[i+1] == '{' {
+			end := strings.IndexByte(value[i:], '}')
+			if end < 0 {
+				continue
+			}
+			ref.name = shName.FindString(value[i+2:])
+			ref.text = value[i : i+end+1]
+		} else {
+			ref.name = shName.FindString(value[i+1:])
+			ref.text = "$" + ref.name
+		}
+		if ref.name != "" {
+			refs = append(refs, ref)
+			i += len(ref.text) - 1
+		}
+	}
+	return refs
+}
+
+// shUnquoted returns value, the shell code after the = of an assignment,
+// without its single-quoted parts and escaped characters, which are only
+// expanded when the prompt is drawn.
+func shUnquoted(value string) string {
+	var b strings.Builder
+	single, double := false, false
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case single:
+			single = c != '\''
+		case c == '\\':
+			i++
+		case c == '\'' && !double:
+			single = true
+		default:
+			if c == '"' {
+				double = !double
+			}
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// lintGitValues checks that values read from git, such as the branch name,
+// only get into a zsh or bash prompt by name. A value expanded into the
+// prompt string when it is assigned is expanded again when the prompt is
+// drawn, so a branch named $(...) would run. Prompt strings are PROMPT, PS1
+// and friends and any variable copied into them; git values are variables
+// set from a git command or built from another git value.
+func lintGitValues(file, content string, shell ShellTarget) []Diagnostic {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			line = ""
+		}
+		lines = append(lines, line)
+	}
+
+	prompts := map[string]bool{"PROMPT": true, "PS1": true, "RPROMPT": true, "RPS1": true}
+	for _, line := range lines {
+		if m := shPromptCopy.FindStringSubmatch(line); m != nil {
+			prompts[m[1]] = true
+		}
+	}
+	fromGit := map[string]bool{}
+	isGit := func(name string) bool { return fromGit[name] || shGitNames.MatchString(name) }
+	for changed := true; changed; {
+		changed = false
+		for _, line := range lines {
+			m := shAssign.FindStringSubmatch(line)
+			if m == nil || isGit(m[1]) || prompts[m[1]] {
+				continue
+			}
+			derived := shGitCommand.MatchString(m[2])
+			for _, ref := range shRefs(m[2]) {
+				derived = derived || !ref.quoted && isGit(ref.name)
+			}
+			if derived {
+				fromGit[m[1]] = true
+				changed = true
+			}
+		}
+	}
+
+	var diags []Diagnostic
+	for i, line := range lines {
+		m := shAssign.FindStringSubmatch(line)
+		if m == nil || !prompts[m[1]] {
+			continue
+		}
+		if shGitCommand.MatchString(shUnquoted(m[2])) {
+			diags = append(diags, Diagnostic{File: file, Line: i + 1, Severity: sevError,
+				Message: fmt.Sprintf("the git command in %s runs when it is set and its output is expanded again when the prompt is drawn, so a branch named $(...) runs; set a variable from git and use it in single quotes", m[1])})
+		}
+		for _, ref := range shRefs(m[2]) {
+			switch {
+			case !isGit(ref.name):
+			case !ref.quoted:
+				diags = append(diags, Diagnostic{File: file, Line: i + 1, Severity: sevError,
+					Message: fmt.Sprintf("%s comes from git and is expanded into %s when it is set, so a branch named $(...) runs when the prompt is drawn; put it in single quotes", ref.text, m[1])})
+			case shell == ShellZsh && !strings.Contains(ref.text, "%%"):
+				diags = append(diags, Diagnostic{File: file, Line: i + 1, Severity: sevWarning,
+					Message: fmt.Sprintf("%s comes from git, so a %% in a branch name is read as a prompt escape; use ${%s//\\%%/%%%%}", ref.text, ref.name)})
+			}
+		}
+	}
+	return diags
+}
+
+// lintFish checks the syntax of a fish theme.
+func lintFish(file, content string) []Diagnostic {
+	return syntaxCheck(file, content, ShellFish)
+}
+
+// starshipKeys are the top-level settings of a starship config that aren't
+// module tables.
+var starshipKeys = map[string]bool{
+	"$schema": true, "format": true, "right_format": true, "continuation_prompt": true,
+	"scan_timeout": true, "command_timeout": true, "add_newline": true, "follow_symlinks": true,
+	"palette": true, "palettes": true,
+}
+
+// starshipModules are the modules starship knows about.
+var starshipModules = map[string]bool{}
+
+func init() {
+	for _, name := range strings.Fields(`
+		aws azure battery buf bun c character cmake cmd_duration cobol conda container cpp
+		crystal custom daml dart deno directory direnv docker_context dotnet elixir elm
+		env_var erlang fennel fill fortran fossil_branch fossil_metrics gcloud git_branch
+		git_commit git_metrics git_state git_status gleam golang gradle guix_shell haskell
+		haxe helm hg_branch hg_state hostname java jobs julia kotlin kubernetes line_break
+		localip lua memory_usage meson mojo nats netns nim nix_shell nodejs ocaml odin opa
+		openstack os package perl php pijul_channel pixi pulumi purescript python quarto
+		raku red rlang ruby rust scala shell shlvl singularity solidity spack status sudo
+		swift terraform time typst username vagrant vcsh vlang xmake zig`) {
+		starshipModules[name] = true
+	}
+}
+
+// starshipColors are the color names starship styles accept, besides their
+// bright- variants, hex values, 0-255 and palette colors.
+var starshipColors = map[string]bool{
+	"black": true, "red": true, "green": true, "blue": true,
+	"yellow": true, "purple": true, "cyan": true, "white": true,
+}
+
+// starshipStyleWords are the parts of a style string that aren't colors.
+var starshipStyleWords = map[string]bool{
+	"bold": true, "italic": true, "underline": true, "dimmed": true, "inverted": true,
+	"blink": true, "hidden": true, "strikethrough": true, "none": true,
+	"prev_fg": true, "prev_bg": true,
+}
+
+var (
+	hexStyleColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	// formatStyle finds the style of a [text](style) group in a format string.
+	formatStyle = regexp.MustCompile(`\]\(([^)]*)\)`)
+	customRef   = regexp.MustCompile(`\$\{?custom\.([A-Za-z0-9_-]+)`)
+)
+
+// checkStyle checks a starship style string, e.g. "bold fg:#ff0000 bg:blue",
+// against the colors starship knows and those in the active palette.
+func checkStyle(style string, palette map[string]bool) error {
+	for _, word := range strings.Fields(style) {
+		if strings.HasPrefix(word, "$") {
+			continue // a variable, e.g. $style
+		}
+		lower := strings.ToLower(word)
+		if starshipStyleWords[lower] {
+			continue
+		}
+		c := word
+		if i := strings.IndexByte(lower, ':'); i >= 0 && (lower[:i] == "fg" || lower[:i] == "bg") {
+			c = word[i+1:]
+		}
+		lc := strings.ToLower(c)
+		switch {
+		case starshipColors[lc], starshipColors[strings.TrimPrefix(lc, "bright-")]:
+		case hexStyleColor.MatchString(c), palette[c], lc == "none", lc == "prev_fg", lc == "prev_bg":
+		default:
+			if n, err := strconv.Atoi(c); err == nil && n >= 0 && n <= 255 {
+				continue
+			}
+			return fmt.Errorf("%q is not a color or style", word)
+		}
+	}
+	return nil
+}
+
+// tableHeader finds the line starting the table [path] in content, or 0.
+func tableHeader(content, path string) int {
+	re := regexp.MustCompile(`(?m)^[ \t]*\[[ \t]*` + regexp.QuoteMeta(path) + `[ \t]*\]`)
+	if loc := re.FindStringIndex(content); loc != nil {
+		return lineAt(content, loc[0])
+	}
+	return 0
+}
+
+// findLine returns the line of the first needle at or after line from in
+// content, or from if there is none.
+func findLine(content, needle string, from int) int {
+	offset := 0
+	for i := 1; i < from && offset < len(content); i++ {
+		next := strings.IndexByte(content[offset:], '\n')
+		if next < 0 {
+			break
+		}
+		offset += next + 1
+	}
+	if i := strings.Index(content[offset:], needle); i >= 0 {
+		return lineAt(content, offset+i)
+	}
+	return from
+}
+
+// lintStarship checks a starship config: that it parses, that every table is
+// a module starship knows, that its styles are valid and that every
+// $custom.<name> in a format has a [custom.<name>] table.
+func lintStarship(file, content string) []Diagnostic {
+	var config map[string]any
+	if _, err := toml.Decode(content, &config); err != nil {
+		return []Diagnostic{tomlDiagnostic(file, err)}
+	}
+
+	palette := map[string]bool{}
+	if name, ok := config["palette"].(string); ok {
+		palettes, _ := config["palettes"].(map[string]any)
+		colors, ok := palettes[name].(map[string]any)
+		if !ok {
+			line := findLine(content, "palette", 1)
+			return []Diagnostic{{File: file, Line: line, Severity: sevError, Message: fmt.Sprintf("palette %q has no [palettes.%s] table", name, name)}}
+		}
+		for c := range colors {
+			palette[c] = true
+		}
+	}
+	customs, _ := config["custom"].(map[string]any)
+
+	var diags []Diagnostic
+	var walk func(path []string, key string, value any)
+	walk = func(path []string, key string, value any) {
+		table := strings.Join(path, ".")
+		switch v := value.(type) {
+		case map[string]any:
+			for k, child := range v {
+				walk(append(path, k), k, child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(path, key, child)
+			}
+		case string:
+			// The key sits in the table named by all but the last part.
+			header := tableHeader(content, strings.Join(path[:len(path)-1], "."))
+			if key == "style" || strings.HasSuffix(key, "_style") {
+				if err := checkStyle(v, palette); err != nil {
+					diags = append(diags, Diagnostic{File: file, Line: findLine(content, key, header), Severity: sevError,
+						Message: fmt.Sprintf("%s: bad style: %v", table, err)})
+				}
+			}
+			for _, m := range formatStyle.FindAllStringSubmatch(v, -1) {
+				if err := checkStyle(m[1], palette); err != nil {
+					diags = append(diags, Diagnostic{File: file, Line: findLine(content, m[0], header), Severity: sevError,
+						Message: fmt.Sprintf("%s: bad style: %v", table, err)})
+				}
+			}
+			for _, m := range customRef.FindAllStringSubmatch(v, -1) {
+				if _, ok := customs[m[1]]; !ok {
+					diags = append(diags, Diagnostic{File: file, Line: findLine(content, m[0], header), Severity: sevError,
+						Message: fmt.Sprintf("%s refers to $custom.%s, but there is no [custom.%s] table", table, m[1], m[1])})
+				}
+			}
+		}
+	}
+
+	for key, value := range config {
+		_, isTable := value.(map[string]any)
+		switch {
+		case key == "palettes":
+			continue
+		case starshipKeys[key], starshipModules[key]:
+			walk([]string{key}, key, value)
+		case isTable:
+			diags = append(diags, Diagnostic{File: file, Line: tableHeader(content, key), Severity: sevError,
+				Message: fmt.Sprintf("unknown module [%s]", key)})
+		default:
+			diags = append(diags, Diagnostic{File: file, Line: findLine(content, key, 1), Severity: sevError,
+				Message: fmt.Sprintf("unknown setting %q", key)})
+		}
+	}
+
+	sortDiagnostics(diags)
+	return diags
+}
+
+// sortDiagnostics orders the diagnostics of one file by line.
+func sortDiagnostics(diags []Diagnostic) {
+	sort.Slice(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		return diags[i].Message < diags[j].Message
+	})
+}
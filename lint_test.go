@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// lintCase is a theme file and the one diagnostic lint should find in it, or
+// none when sev is "".
+type lintCase struct {
+	name    string
+	content string
+	line    int
+	want    string // part of the message
+	sev     string
+}
+
+func checkLint(t *testing.T, lint func(file, content string) []Diagnostic, tests []lintCase) {
+	t.Helper()
+	for _, tt := range tests {
+		diags := lint("theme", tt.content)
+		switch {
+		case tt.sev == "" && len(diags) != 0:
+			t.Errorf("%s: unexpected %v", tt.name, diags)
+		case tt.sev == "":
+		case len(diags) != 1 || diags[0].Line != tt.line || diags[0].Severity != tt.sev || !strings.Contains(diags[0].Message, tt.want):
+			t.Errorf("%s: got %v, want a %s on line %d about %q", tt.name, diags, tt.sev, tt.line, tt.want)
+		}
+	}
+}
+
+func TestLintZsh(t *testing.T) {
+	checkLint(t, lintZsh, []lintCase{
+		{"fine", "setopt prompt_subst\nPROMPT='%F{cyan}%~%f %% '\n", 0, "", ""},
+		{"unended color", "setopt prompt_subst\nPROMPT='%F{cyan}%~ '\n", 2, "never ended", sevError},
+		{"stray end", "setopt prompt_subst\nPROMPT='%~%f '\n", 2, "without a %F", sevWarning},
+		{"missing prompt_subst", "PROMPT='%F{cyan}%~%f '\n", 1, "prompt_subst is missing", sevError},
+		{"prompt_subst in a comment", "# setopt prompt_subst\nPROMPT='%~ '\n", 2, "prompt_subst is missing", sevError},
+		{"precmd", "setopt prompt_subst\nprecmd() {\n  PROMPT='%~ '\n}\n", 2, "add-zsh-hook", sevWarning},
+		{"precmd_functions", "setopt prompt_subst\nprecmd_functions=(mine)\nPROMPT='%~ '\n", 2, "add-zsh-hook", sevWarning},
+	})
+}
+
+func TestLintBash(t *testing.T) {
+	requireShell(t, "bash")
+	checkLint(t, lintBash, []lintCase{
+		{"fine", "PS1='\\w \\$ '\n", 0, "", ""},
+		{"syntax error", "PS1='\\w \\$ '\nif true; then\n  :\n", 4, "syntax error", sevError},
+	})
+}
+
+func TestLintFish(t *testing.T) {
+	requireShell(t, "fish")
+	checkLint(t, lintFish, []lintCase{
+		{"fine", "function fish_prompt\n  echo '> '\nend\n", 0, "", ""},
+		{"missing end", "function fish_prompt\n  echo '> '\n", 1, "", sevError},
+	})
+}
+
+func TestLintStarship(t *testing.T) {
+	checkLint(t, lintStarship, []lintCase{
+		{"fine", "format = \"$directory${custom.host}$character\"\n\n[directory]\nstyle = \"bold fg:#ff0000 bg:blue\"\n\n[custom.host]\ncommand = \"hostname\"\nformat = \"[$output](bright-purple) \"\n", 0, "", ""},
+		{"unknown module", "[directory]\nstyle = \"cyan\"\n\n[direktory]\nstyle = \"cyan\"\n", 4, "unknown module [direktory]", sevError},
+		{"unknown setting", "formt = \"$all\"\n", 1, `unknown setting "formt"`, sevError},
+		{"bad style", "[directory]\nstyle = \"bold sky\"\n", 2, `"sky" is not a color`, sevError},
+		{"bad style in a format", "[git_branch]\nformat = \"[$branch](fg:#12345z) \"\n", 2, "bad style", sevError},
+		{"palette color", "palette = \"mine\"\n\n[palettes.mine]\nsand = \"#C1A78E\"\n\n[directory]\nstyle = \"sand\"\n", 0, "", ""},
+		{"missing palette", "palette = \"mine\"\n", 1, "no [palettes.mine] table", sevError},
+		{"dangling custom", "format = \"$directory${custom.host}\"\n", 1, "no [custom.host] table", sevError},
+		{"broken toml", "[directory]\nstyle = \"cyan\n", 2, "", sevError},
+	})
+}
+
+// TestLintBuiltinThemes checks that every built-in theme passes lint.
+func TestLintBuiltinThemes(t *testing.T) {
+	themes, err := loadThemes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, theme := range themes {
+		if theme.IsCustom {
+			continue
+		}
+		for _, d := range lintTheme(theme, "") {
+			t.Errorf("%s", d)
+		}
+	}
+}
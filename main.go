@@ -1,35 +1,58 @@
 package main
 
 import (
-	"bufio"
 	"embed"
+	"errors"
+	"flag"
 	"fmt"
 	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
 )
 
-//go:embed *.promptly.zsh
-var zshFiles embed.FS
-
-//go:embed *.promptly.fish
-var fishFiles embed.FS
-
-//go:embed *.promptly.toml
-var starshipFiles embed.FS
+// themeFiles holds the built-in themes: specs, hand-written shell variants
+// and manifests.
+//
+//go:embed *.promptly.*
+var themeFiles embed.FS
 
 type ShellTarget string
 
 const (
 	ShellZsh      ShellTarget = "zsh"
+	ShellBash     ShellTarget = "bash"
 	ShellFish     ShellTarget = "fish"
+	ShellPwsh     ShellTarget = "pwsh"
+	ShellNu       ShellTarget = "nu"
 	ShellStarship ShellTarget = "starship"
 )
 
+// allShellTargets lists every ShellTarget in menu order.
+var allShellTargets = []ShellTarget{ShellZsh, ShellBash, ShellFish, ShellPwsh, ShellNu, ShellStarship}
+
+// themeSuffixes maps each ShellTarget to the file suffix of its theme variant.
+var themeSuffixes = map[ShellTarget]string{
+	ShellZsh:      ".promptly.zsh",
+	ShellBash:     ".promptly.bash",
+	ShellFish:     ".promptly.fish",
+	ShellPwsh:     ".promptly.ps1",
+	ShellNu:       ".promptly.nu",
+	ShellStarship: ".promptly.toml",
+}
+
+// Names of the selector entries that are actions rather than themes.
+const (
+	createCustomEntry = "Create Custom"
+	manageCustomEntry = "Manage Custom"
+)
+
 type Theme struct {
 	Name        string
 	Description string
@@ -37,9 +60,33 @@ type Theme struct {
 	Preview     string
 	IsCustom    bool
 	SourcePath  string
+	Meta        ThemeMeta
+	// Spec is the spec Contents was compiled from, if the theme has one.
+	Spec *ThemeSpec
+	// SaveSpec is set on a custom theme created or edited in this run, whose
+	// spec still has to be written to the config directory.
+	SaveSpec bool
 }
 
 func main() {
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if err := runCommand(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	opts, err := parseInteractiveFlags(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
 	themes, err := loadThemes()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading themes: %v\n", err)
@@ -51,49 +98,151 @@ func main() {
 		os.Exit(1)
 	}
 
-	shell, err := selectShell()
+	detected := detectShell()
+
+	shell, err := selectShell(detected)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error selecting shell: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Filter to themes that support the selected shell
-	var supported []Theme
-	for _, t := range themes {
-		if t.IsCustom && t.Name == "Create Custom" {
-			supported = append(supported, t)
-			continue
-		}
-		if _, ok := t.Contents[shell]; ok {
-			supported = append(supported, t)
-		}
+	palettes, err := loadPalettes()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading palettes: %v\n", err)
+		os.Exit(1)
 	}
 
-	if len(supported) == 0 {
-		fmt.Printf("No themes available for %s\n", shell)
-		os.Exit(1)
+	var palette *Palette
+	if opts.Palette != "" {
+		p, err := findPalette(palettes, opts.Palette)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		palette = &p
 	}
 
-	selectedTheme, err := selectTheme(supported, shell)
+	var selectedTheme Theme
+	for {
+		supported := supportedThemes(themes, shell, palette)
+		if len(supported) == 0 {
+			fmt.Printf("No themes available for %s\n", shell)
+			os.Exit(1)
+		}
+
+		selectedTheme, err = selectTheme(supported, shell, opts.DryRun)
+		if !errors.Is(err, errThemesChanged) {
+			break
+		}
+		// A custom theme was renamed, copied or deleted: show the
+		// selector again with the themes as they are now.
+		if themes, err = loadThemes(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading themes: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error selecting theme: %v\n", err)
 		os.Exit(1)
 	}
 
-	if err := installTheme(selectedTheme, shell); err != nil {
+	warnMissingNerdFont(selectedTheme)
+
+	switch {
+	case opts.Palette != "" && selectedTheme.Spec == nil:
+		fmt.Fprintf(os.Stderr, "Error: theme %q has no theme spec, so it can't be recolored with a palette\n", selectedTheme.Name)
+		os.Exit(1)
+	case opts.Palette == "" && selectedTheme.Spec != nil && len(palettes) > 0:
+		opts.Palette, err = selectPalette(selectedTheme, palettes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error selecting palette: %v\n", err)
+			os.Exit(1)
+		}
+		if opts.Palette != "" {
+			palette, _ := findPalette(palettes, opts.Palette)
+			if selectedTheme, err = selectedTheme.withPalette(palette); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if shell == ShellStarship {
+		opts.StarshipShell, err = selectStarshipShell(detected)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error selecting shell: %v\n", err)
+			os.Exit(1)
+		}
+		warnMissingStarship(detected)
+	}
+
+	if err := installTheme(selectedTheme, shell, opts); err != nil {
 		fmt.Fprintf(os.Stderr, "Error installing theme: %v\n", err)
 		os.Exit(1)
 	}
 
-	color.Green("✓ Theme '%s' installed successfully for %s!", selectedTheme.Name, shell)
+	if !opts.DryRun {
+		printInstallSuccess(selectedTheme, shell, opts)
+	}
+}
 
-	switch shell {
-	case ShellZsh:
-		fmt.Println("Restart your terminal or run 'source ~/.zshrc' to apply the changes.")
-	case ShellFish:
-		fmt.Println("Restart your terminal or run 'source ~/.config/fish/config.fish' to apply the changes.")
-	case ShellStarship:
-		fmt.Println("Restart your terminal to apply the changes.")
+// supportedThemes returns the themes with a variant for shell, along with the
+// selector's menu entries. With a palette the previews are drawn in it too.
+func supportedThemes(themes []Theme, shell ShellTarget, palette *Palette) []Theme {
+	var supported []Theme
+	for _, t := range themes {
+		if isMenuEntry(t) {
+			supported = append(supported, t)
+			continue
+		}
+		if _, ok := t.Contents[shell]; !ok {
+			continue
+		}
+		if palette != nil && t.Spec != nil {
+			t, _ = t.withPalette(*palette)
+		}
+		supported = append(supported, t)
+	}
+	return supported
+}
+
+func printInstallSuccess(theme Theme, shell ShellTarget, opts InstallOptions) {
+	if opts.Palette != "" {
+		color.Green("✓ Theme '%s' installed successfully for %s with the %s palette!", theme.Name, shell, opts.Palette)
+	} else {
+		color.Green("✓ Theme '%s' installed successfully for %s!", theme.Name, shell)
+	}
+
+	rcShell := string(shell)
+	if shell == ShellStarship {
+		rcShell = opts.StarshipShell
+	}
+	if paths, err := resolvePaths(); err == nil {
+		rc := paths.RCFile(rcShell)
+		label := displayPath(paths.Home, rc)
+		if origin, ok := paths.Origins[rcLabels[rcShell]]; ok {
+			fmt.Printf("Updated %s (chosen via %s).\n", label, origin)
+		} else {
+			fmt.Printf("Updated %s.\n", label)
+		}
+		switch shell {
+		case ShellStarship, ShellNu:
+			fmt.Println("Restart your terminal to apply the changes.")
+		case ShellPwsh:
+			fmt.Println("Restart your terminal or run '. $PROFILE' to apply the changes.")
+		default:
+			fmt.Printf("Restart your terminal or run 'source %s' to apply the changes.\n", label)
+		}
+	}
+
+	if theme.IsCustom && theme.SourcePath != "" {
+		fmt.Printf("Your custom theme lives at %s. You can edit this file to customize it.\n", theme.SourcePath)
+	}
+	if theme.IsCustom && installedContent(theme, shell) != theme.Contents[shell] {
+		fmt.Println("--no-icons installed a copy with the glyphs replaced, so install the theme again after editing it.")
+	}
+	if opts.Native {
+		fmt.Printf("The prompt is drawn by %s, so keep it installed.\n", promptlyCommand())
 	}
 }
 
@@ -101,25 +250,39 @@ func main() {
 // Shell selection
 // ─────────────────────────────────────────────────────────────
 
-func selectShell() (ShellTarget, error) {
+// selectShell asks for the target to install for, with the detected shell
+// preselected.
+func selectShell(detected Detection) (ShellTarget, error) {
 	shells := []struct {
 		Label string
 		Value ShellTarget
 	}{
 		{"zsh", ShellZsh},
+		{"bash", ShellBash},
 		{"fish", ShellFish},
+		{"pwsh (PowerShell)", ShellPwsh},
+		{"nu (Nushell)", ShellNu},
 		{"starship (shell-agnostic)", ShellStarship},
 	}
 
 	labels := make([]string, len(shells))
+	cursor := 0
 	for i, s := range shells {
 		labels[i] = s.Label
+		switch {
+		case s.Value == detected.Shell:
+			labels[i] += " (detected)"
+			cursor = i
+		case s.Value == ShellStarship && detected.Starship:
+			labels[i] = "starship (shell-agnostic, found on PATH)"
+		}
 	}
 
 	prompt := promptui.Select{
-		Label: "Select your shell",
-		Items: labels,
-		Size:  len(labels),
+		Label:     "Select your shell",
+		Items:     labels,
+		Size:      len(labels),
+		CursorPos: cursor,
 	}
 
 	i, _, err := prompt.Run()
@@ -130,64 +293,124 @@ func selectShell() (ShellTarget, error) {
 	return shells[i].Value, nil
 }
 
-func selectStarshipShell() (string, error) {
-	shells := []string{"zsh", "bash", "fish"}
+// starshipShells lists the shells installStarship knows how to wire up.
+var starshipShells = []string{"zsh", "bash", "fish", "pwsh", "nu"}
+
+// paletteChoice is an entry in the palette picker, with the selected theme's
+// preview drawn in the palette.
+type paletteChoice struct {
+	Name        string
+	Description string
+	Preview     string
+}
+
+// selectPalette asks which palette to recolor theme with. It returns "" to
+// keep the theme's own colors.
+func selectPalette(theme Theme, palettes []Palette) (string, error) {
+	choices := []paletteChoice{{
+		Name:        "theme colors",
+		Description: "The colors " + theme.Name + " comes with",
+		Preview:     theme.Preview,
+	}}
+	for _, p := range palettes {
+		recolored, err := theme.withPalette(p)
+		if err != nil {
+			return "", err
+		}
+		choices = append(choices, paletteChoice{Name: p.Name, Description: p.Description, Preview: recolored.Preview})
+	}
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}:",
+		Active:   "▸ {{ .Name | cyan }} - {{ .Description }}",
+		Inactive: "  {{ .Name | cyan }} - {{ .Description }}",
+		Selected: "{{ .Name | red | cyan }}",
+		Details: `
+--------- Preview ---------
+{{ .Preview }}`,
+	}
 
 	prompt := promptui.Select{
-		Label: "Which shell are you running starship on top of?",
-		Items: shells,
-		Size:  len(shells),
+		Label:     "Select a color palette",
+		Items:     choices,
+		Templates: templates,
+		Size:      len(choices),
 	}
 
 	i, _, err := prompt.Run()
 	if err != nil {
 		return "", err
 	}
-
-	return shells[i], nil
+	if i == 0 {
+		return "", nil
+	}
+	return choices[i].Name, nil
 }
 
-// ─────────────────────────────────────────────────────────────
-// Theme loading
-// ─────────────────────────────────────────────────────────────
+func selectStarshipShell(detected Detection) (string, error) {
+	labels := make([]string, len(starshipShells))
+	cursor := 0
+	for i, s := range starshipShells {
+		labels[i] = s
+		if s == string(detected.Shell) {
+			labels[i] += " (detected)"
+			cursor = i
+		}
+	}
 
-func loadThemes() ([]Theme, error) {
-	themeMap := make(map[string]*Theme)
+	prompt := promptui.Select{
+		Label:     "Which shell are you running starship on top of?",
+		Items:     labels,
+		Size:      len(labels),
+		CursorPos: cursor,
+	}
 
-	load := func(fsys embed.FS, ext string, shell ShellTarget) error {
-		return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			if !strings.HasSuffix(path, ext) {
-				return nil
-			}
-			content, err := fsys.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			name := strings.TrimSuffix(filepath.Base(path), ext)
-			if _, ok := themeMap[name]; !ok {
-				themeMap[name] = &Theme{
-					Name:        name,
-					Description: getThemeDescription(name),
-					Contents:    make(map[ShellTarget]string),
-					Preview:     generatePreview(name),
-					IsCustom:    false,
-				}
-			}
-			themeMap[name].Contents[shell] = string(content)
-			return nil
-		})
+	i, _, err := prompt.Run()
+	if err != nil {
+		return "", err
 	}
 
-	if err := load(zshFiles, ".promptly.zsh", ShellZsh); err != nil {
-		return nil, err
+	return starshipShells[i], nil
+}
+
+// warnMissingStarship warns that a starship theme won't show up until
+// starship itself is installed.
+func warnMissingStarship(detected Detection) {
+	if !detected.Starship {
+		color.Yellow("! starship was not found on your PATH. Install it from https://starship.rs to use this theme.")
 	}
-	if err := load(fishFiles, ".promptly.fish", ShellFish); err != nil {
-		return nil, err
+}
+
+func selectBackup(backups []Backup) (Backup, error) {
+	labels := make([]string, len(backups))
+	for i, b := range backups {
+		labels[i] = fmt.Sprintf("%s  %s", b.Time.Format("2006-01-02 15:04:05"), b.Original)
+	}
+
+	prompt := promptui.Select{
+		Label: "Select a backup to restore",
+		Items: labels,
+		Size:  min(len(labels), 10),
+	}
+
+	i, _, err := prompt.Run()
+	if err != nil {
+		return Backup{}, err
 	}
-	if err := load(starshipFiles, ".promptly.toml", ShellStarship); err != nil {
+
+	return backups[i], nil
+}
+
+// ─────────────────────────────────────────────────────────────
+// Theme loading
+// ─────────────────────────────────────────────────────────────
+
+func loadThemes() ([]Theme, error) {
+	themeMap, err := readThemes(themeFiles, Theme{
+		Description: "Custom promptly theme",
+		Preview:     "Preview not available",
+	}, true)
+	if err != nil {
 		return nil, err
 	}
 
@@ -195,6 +418,7 @@ func loadThemes() ([]Theme, error) {
 	for _, t := range themeMap {
 		themes = append(themes, *t)
 	}
+	sortThemes(themes)
 
 	// Load custom themes from ~/.config/promptly
 	customThemes, err := loadCustomThemes()
@@ -203,154 +427,145 @@ func loadThemes() ([]Theme, error) {
 	}
 
 	themes = append(themes, Theme{
-		Name:        "Create Custom",
+		Name:        createCustomEntry,
 		Description: "Create a custom theme based on an existing one",
 		Contents:    map[ShellTarget]string{},
 		Preview:     "Select this to create a custom theme you can edit",
 		IsCustom:    true,
 	})
+	if len(customThemes) > 0 {
+		themes = append(themes, Theme{
+			Name:        manageCustomEntry,
+			Description: "Rename, copy or delete your custom themes",
+			Contents:    map[ShellTarget]string{},
+			Preview:     "Select this to manage the themes in your config directory",
+			IsCustom:    true,
+		})
+	}
 
 	return themes, nil
 }
 
-func getThemeDescription(name string) string {
-	switch name {
-	case "default":
-		return "Clean text-based prompt with git status"
-	case "icons":
-		return "Nerd Font icons with enhanced git visualization"
-	case "semicolon":
-		return "ASCII-only prompt with semicolon prompt character"
-	case "melange":
-		return "Warm color palette inspired by the Melange Neovim theme"
-	case "owly":
-		return "Detailed starship prompt with semi colon prompt character in the Owly color scheme."
-	case "owly-simple":
-		return "Single-line owly variant without GitHub detection, arrow prompt character."
-	default:
-		return "Custom promptly theme"
-	}
+// isMenuEntry reports whether t is one of the selector's actions rather than
+// a theme.
+func isMenuEntry(t Theme) bool {
+	return t.IsCustom && (t.Name == createCustomEntry || t.Name == manageCustomEntry)
 }
 
-// ─────────────────────────────────────────────────────────────
-// Preview generation — melange palette throughout
-// ─────────────────────────────────────────────────────────────
+// readThemes loads every theme in fsys, starting each from defaults. A
+// theme's spec is compiled for every shell; a hand-written shell file takes
+// precedence over the compiled one, unless it is itself a compiled copy. In
+// strict mode any broken file is an error, otherwise that file is skipped.
+// When defaults.SourcePath is set it is the directory fsys reads, and each
+// theme's SourcePath becomes its spec or else its first shell file there.
+func readThemes(fsys fs.FS, defaults Theme, strict bool) (map[string]*Theme, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
 
-func mel(hex, text string) string {
-	var r, g, b int
-	fmt.Sscanf(hex[1:], "%02x%02x%02x", &r, &g, &b)
-	return fmt.Sprintf("\033[38;2;%d;%d;%dm%s\033[0m", r, g, b, text)
-}
-
-func generatePreview(name string) string {
-	switch name {
-	case "melange":
-		return melangePreview()
-	case "default":
-		return defaultPreview()
-	case "icons":
-		return iconsPreview()
-	case "semicolon":
-		return semicolonPreview()
-	case "owly":
-		return owlyPreview()
-	case "owly-simple":
-		return owlySimplePreview()
-	default:
-		return "Preview not available for custom themes"
-	}
-}
-
-func melangePreview() string {
-	dir := mel("#C1A78E", "~/projects/myapp")
-	on := mel("#867462", "on")
-	gitIcon := mel("#89B3B6", "\uf408 \ue725 ")
-	branch := mel("#A3A9CE", "main")
-	ahead := mel("#89B3B6", "⇡1")
-	staged := mel("#85B695", "+2")
-	unstaged := mel("#EBC06D", "!1")
-	untracked := mel("#D47766", "?3")
-	promptChar := mel("#89B3B6", ";")
-
-	line1 := dir + " " + on + " " + gitIcon + branch + " " + ahead + " " + staged + " " + unstaged + " " + untracked
-	line2 := promptChar + " "
-	return line1 + "\n" + line2
-}
-
-func owlyPreview() string {
-	dir := mel("#AF9374", "~/projects/myapp")
-	on := mel("#4B5345", "on")
-	gitIcon := mel("#3ad0b5", "\uf113 \ue725 ")
-	branch := mel("#3ad0b5", "main")
-	ahead := mel("#3ad0b5", "⇡1")
-	staged := mel("#3ad0b5", "+2")
-	unstaged := mel("#E6DB74", "!1")
-	untracked := mel("#C47B6B", "?3")
-	promptChar := mel("#3ad0b5", ";")
-
-	line1 := dir + " " + on + " " + gitIcon + branch + " " + ahead + " " + staged + " " + unstaged + " " + untracked
-	line2 := promptChar + " "
-	return line1 + "\n" + line2
-}
-
-func owlySimplePreview() string {
-	dir := mel("#AF9374", "~/projects/myapp")
-	on := mel("#4B5345", "on")
-	branch := mel("#3ad0b5", "\ue725 main")
-	staged := mel("#3ad0b5", "+2")
-	unstaged := mel("#E6DB74", "!1")
-	untracked := mel("#C47B6B", "?3")
-	promptChar := mel("#3ad0b5", "➜")
-
-	return promptChar + " " + dir + " " + on + " " + branch + " " + staged + " " + unstaged + " " + untracked + " " 
-}
-
-func defaultPreview() string {
-	dir := color.New(color.FgCyan).Sprint("~/projects/myapp")
-	git := color.New(color.FgBlue).Sprint("git(") +
-		color.New(color.FgMagenta).Sprint("main") +
-		color.New(color.FgBlue).Sprint(")")
-	status := color.New(color.FgGreen).Sprint("+2") + " " +
-		color.New(color.FgYellow).Sprint("!1") + " " +
-		color.New(color.FgRed).Sprint("?3")
-	return dir + " " + git + " " + status + "\n" + color.New(color.FgBlue).Sprint("❯") + " "
-}
-
-func iconsPreview() string {
-	dir := color.New(color.FgCyan).Sprint("~/projects/myapp")
-	git := color.New(color.FgWhite).Sprint("on") + " " +
-		color.New(color.FgBlue).Sprint("\uf1d3 \uf418 ") +
-		color.New(color.FgMagenta).Sprint("main")
-	status := color.New(color.FgGreen).Sprint("+2") + " " +
-		color.New(color.FgYellow).Sprint("!1") + " " +
-		color.New(color.FgRed).Sprint("?3")
-	return dir + " " + git + " " + status + "\n" + color.New(color.FgBlue).Sprint("❯") + " "
-}
-
-func semicolonPreview() string {
-	dir := color.New(color.FgCyan).Sprint("~/projects/myapp")
-	git := color.New(color.FgWhite).Sprint("git(") +
-		color.New(color.FgMagenta).Sprint("main") +
-		color.New(color.FgWhite).Sprint(")")
-	status := color.New(color.FgGreen).Sprint("+2") + " " +
-		color.New(color.FgYellow).Sprint("!1") + " " +
-		color.New(color.FgRed).Sprint("?3")
-	return dir + " " + git + " " + status + "\n" + color.New(color.FgBlue).Sprint(";") + " "
+	themeMap := make(map[string]*Theme)
+	get := func(name, file string) *Theme {
+		if t, ok := themeMap[name]; ok {
+			return t
+		}
+		t := defaults
+		t.Name = name
+		t.Contents = make(map[ShellTarget]string)
+		if defaults.SourcePath != "" {
+			t.SourcePath = filepath.Join(defaults.SourcePath, file)
+		}
+		themeMap[name] = &t
+		return &t
+	}
+	skip := func(file string, err error) error {
+		if strict {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		return nil
+	}
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), specSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err == nil {
+			var spec ThemeSpec
+			if spec, err = parseThemeSpec(data); err == nil {
+				t := get(name, entry.Name())
+				t.Spec = &spec
+				t.Contents = compileTheme(name, spec)
+				continue
+			}
+		}
+		if err := skip(entry.Name(), err); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		for _, shell := range allShellTargets {
+			name, ok := strings.CutSuffix(entry.Name(), themeSuffixes[shell])
+			if !ok {
+				continue
+			}
+			data, err := fs.ReadFile(fsys, entry.Name())
+			if err != nil {
+				if err := skip(entry.Name(), err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			t := get(name, entry.Name())
+			if t.Spec != nil && isGenerated(string(data)) {
+				continue
+			}
+			t.Contents[shell] = string(data)
+		}
+	}
+
+	for name, t := range themeMap {
+		data, err := fs.ReadFile(fsys, name+metaSuffix)
+		if err != nil {
+			continue
+		}
+		meta, err := parseThemeMeta(data)
+		if err != nil {
+			if err := skip(name+metaSuffix, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		applyMeta(t, meta)
+	}
+	return themeMap, nil
 }
 
 // ─────────────────────────────────────────────────────────────
 // Theme selection UI
 // ─────────────────────────────────────────────────────────────
 
-func selectTheme(themes []Theme, shell ShellTarget) (Theme, error) {
+// themeDetails shows a theme's preview and manifest under the selector.
+const themeDetails = `
+--------- Preview ---------
+{{ .Preview }}
+{{ with .Meta }}{{ if .Author }}by {{ .Author }}{{ end }}{{ if .Version }} v{{ .Version }}{{ end }}{{ if eq .Font "nerd" }}  (requires a Nerd Font){{ end }}{{ end }}`
+
+// selectTheme asks for the theme to install. Picking the manage entry returns
+// errThemesChanged once a custom theme was changed, so the caller can load
+// the themes again.
+func selectTheme(themes []Theme, shell ShellTarget, dryRun bool) (Theme, error) {
 	templates := &promptui.SelectTemplates{
 		Label:    "{{ . }}:",
 		Active:   "▸ {{ .Name | cyan }} - {{ .Description }}",
 		Inactive: "  {{ .Name | cyan }} - {{ .Description }}",
 		Selected: "{{ .Name | red | cyan }}",
-		Details: `
---------- Preview ---------
-{{ .Preview }}`,
+		Details:  themeDetails,
 	}
 
 	prompt := promptui.Select{
@@ -365,8 +580,14 @@ func selectTheme(themes []Theme, shell ShellTarget) (Theme, error) {
 		return Theme{}, err
 	}
 
-	if themes[i].Name == "Create Custom" {
+	switch themes[i].Name {
+	case createCustomEntry:
 		return selectCustomThemeBase(themes, shell)
+	case manageCustomEntry:
+		if err := manageCustomThemes(shell, dryRun); err != nil {
+			return Theme{}, err
+		}
+		return Theme{}, errThemesChanged
 	}
 
 	return themes[i], nil
@@ -376,180 +597,382 @@ func selectTheme(themes []Theme, shell ShellTarget) (Theme, error) {
 // Theme installation
 // ─────────────────────────────────────────────────────────────
 
-func installTheme(theme Theme, shell ShellTarget) error {
-	switch shell {
-	case ShellZsh:
-		return installZsh(theme)
-	case ShellFish:
-		return installFish(theme)
-	case ShellStarship:
-		return installStarship(theme)
+// InstallOptions carries the answers installTheme would otherwise have to
+// ask for interactively.
+type InstallOptions struct {
+	// StarshipShell is the shell starship runs on top of (zsh, bash or fish).
+	StarshipShell string
+	// DryRun prints a diff of every change instead of making it.
+	DryRun bool
+	// Palette names the palette the theme was recolored with, or is empty
+	// when it keeps its own colors.
+	Palette string
+	// Native installs a hook that draws the prompt with promptly prompt in
+	// place of the compiled theme (zsh, bash and fish only).
+	Native bool
+}
+
+// installTheme installs theme for shell as a single transaction: either every
+// file and rc edit is applied, or none is. With opts.DryRun it prints the
+// changes as unified diffs and leaves the disk untouched.
+func installTheme(theme Theme, shell ShellTarget, opts InstallOptions) error {
+	install := func(tx *Transaction) error {
+		if theme.IsCustom && theme.Meta.Name != "" {
+			if err := writeCustomMeta(tx, theme); err != nil {
+				return err
+			}
+		}
+		if theme.IsCustom && theme.SaveSpec {
+			if err := writeCustomSpec(tx, theme); err != nil {
+				return err
+			}
+		}
+		theme := theme
+		if opts.Native {
+			theme = nativeTheme(theme, shell, opts)
+		}
+		switch shell {
+		case ShellZsh:
+			return installZsh(tx, theme)
+		case ShellBash:
+			return installBash(tx, theme)
+		case ShellFish:
+			return installFish(tx, theme)
+		case ShellPwsh:
+			return installPwsh(tx, theme)
+		case ShellNu:
+			return installNu(tx, theme)
+		case ShellStarship:
+			return installStarship(tx, theme, opts.StarshipShell)
+		}
+		return fmt.Errorf("unknown shell: %s", shell)
 	}
-	return fmt.Errorf("unknown shell: %s", shell)
+
+	if opts.DryRun {
+		changes, err := planTransaction(install)
+		if err != nil {
+			return err
+		}
+		printPlan(os.Stdout, changes)
+		return nil
+	}
+	return runTransaction(install)
 }
 
-func installZsh(theme Theme) error {
-	homeDir, err := os.UserHomeDir()
+// writeCustomMeta writes the manifest of a custom theme next to its files in
+// the config directory.
+func writeCustomMeta(tx *Transaction, theme Theme) error {
+	paths, err := resolvePaths()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
+	data, err := encodeThemeMeta(theme.Meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode theme manifest: %w", err)
+	}
+	if err := tx.MkdirAll(paths.Config, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return tx.WriteFile(filepath.Join(paths.Config, theme.Name+metaSuffix), data, 0644)
+}
+
+// writeCustomSpec writes the spec of a custom theme to the config directory.
+func writeCustomSpec(tx *Transaction, theme Theme) error {
+	paths, err := resolvePaths()
+	if err != nil {
+		return err
+	}
+	data, err := encodeThemeSpec(*theme.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to encode theme spec: %w", err)
+	}
+	header := fmt.Sprintf("# %s%s\n# %s\n\n", theme.Name, specSuffix, theme.Description)
+	if err := tx.MkdirAll(paths.Config, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return tx.WriteFile(filepath.Join(paths.Config, theme.Name+specSuffix), append([]byte(header), data...), 0644)
+}
 
-	promptlyPath := filepath.Join(homeDir, ".promptly.zsh")
-	zshrcPath := filepath.Join(homeDir, ".zshrc")
+func installZsh(tx *Transaction, theme Theme) error {
+	paths, err := resolvePaths()
+	if err != nil {
+		return err
+	}
 
 	content := theme.Contents[ShellZsh]
+	installed := installedContent(theme, ShellZsh)
 
 	if theme.IsCustom {
-		configDir := filepath.Join(homeDir, ".config", "promptly")
-		if err := os.MkdirAll(configDir, 0755); err != nil {
+		if err := tx.MkdirAll(paths.Config, 0755); err != nil {
 			return fmt.Errorf("failed to create config directory: %w", err)
 		}
-		configThemePath := filepath.Join(configDir, theme.Name+".promptly.zsh")
-		if err := os.WriteFile(configThemePath, []byte(content), 0644); err != nil {
+		configThemePath := filepath.Join(paths.Config, theme.Name+".promptly.zsh")
+		if err := tx.WriteFile(configThemePath, []byte(content), 0644); err != nil {
 			return err
 		}
-		content = fmt.Sprintf("# Promptly theme sourcing\nsource %s\n", configThemePath)
+		if installed == content {
+			installed = customStub(ShellZsh, paths.Home, configThemePath)
+		}
 	}
 
-	if err := os.WriteFile(promptlyPath, []byte(content), 0644); err != nil {
+	if err := tx.WriteFile(paths.ZshTheme, []byte(installed), 0644); err != nil {
 		return err
 	}
 
-	return updateRCFile(zshrcPath, "source ~/.promptly.zsh", "# Promptly - Custom shell prompt theme")
+	return updateRCFile(tx, paths.ZshRC, []string{"source ~/.promptly.zsh"})
 }
 
-func installFish(theme Theme) error {
-	homeDir, err := os.UserHomeDir()
+func installBash(tx *Transaction, theme Theme) error {
+	paths, err := resolvePaths()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
 
-	promptlyDir := filepath.Join(homeDir, ".config", "promptly")
-	if err := os.MkdirAll(promptlyDir, 0755); err != nil {
+	content := theme.Contents[ShellBash]
+	installed := installedContent(theme, ShellBash)
+
+	if theme.IsCustom {
+		if err := tx.MkdirAll(paths.Config, 0755); err != nil {
+			return fmt.Errorf("failed to create config directory: %w", err)
+		}
+		configThemePath := filepath.Join(paths.Config, theme.Name+".promptly.bash")
+		if err := tx.WriteFile(configThemePath, []byte(content), 0644); err != nil {
+			return err
+		}
+		if installed == content {
+			installed = customStub(ShellBash, paths.Home, configThemePath)
+		}
+	}
+
+	if err := tx.WriteFile(paths.BashTheme, []byte(installed), 0644); err != nil {
+		return err
+	}
+
+	return updateRCFile(tx, paths.BashRC, []string{"source ~/.promptly.bash"})
+}
+
+func installFish(tx *Transaction, theme Theme) error {
+	paths, err := resolvePaths()
+	if err != nil {
+		return err
+	}
+
+	promptlyDir := paths.Config
+	if err := tx.MkdirAll(promptlyDir, 0755); err != nil {
 		return fmt.Errorf("failed to create promptly config directory: %w", err)
 	}
 
 	promptlyPath := filepath.Join(promptlyDir, "promptly.fish")
-	configFishPath := filepath.Join(homeDir, ".config", "fish", "config.fish")
 
 	content := theme.Contents[ShellFish]
+	installed := installedContent(theme, ShellFish)
 
 	if theme.IsCustom {
 		configThemePath := filepath.Join(promptlyDir, theme.Name+".promptly.fish")
-		if err := os.WriteFile(configThemePath, []byte(content), 0644); err != nil {
+		if err := tx.WriteFile(configThemePath, []byte(content), 0644); err != nil {
 			return err
 		}
-		content = fmt.Sprintf("# Promptly theme sourcing\nsource %s\n", configThemePath)
+		if installed == content {
+			installed = customStub(ShellFish, paths.Home, configThemePath)
+		}
 	}
 
-	if err := os.WriteFile(promptlyPath, []byte(content), 0644); err != nil {
+	if err := tx.WriteFile(promptlyPath, []byte(installed), 0644); err != nil {
 		return err
 	}
 
-	return updateRCFile(configFishPath, fmt.Sprintf("source %s", promptlyPath), "# Promptly - Custom shell prompt theme")
+	return updateRCFile(tx, paths.FishConfig, []string{fmt.Sprintf("source %s", shellPath(ShellFish, paths.Home, promptlyPath))})
 }
 
-func installStarship(theme Theme) error {
-	homeDir, err := os.UserHomeDir()
+// installPwsh writes the theme, which defines the PowerShell prompt function,
+// and dot-sources it from $PROFILE.
+func installPwsh(tx *Transaction, theme Theme) error {
+	paths, err := resolvePaths()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
 
-	promptlyDir := filepath.Join(homeDir, ".config", "promptly")
-	if err := os.MkdirAll(promptlyDir, 0755); err != nil {
+	promptlyDir := paths.Config
+	if err := tx.MkdirAll(promptlyDir, 0755); err != nil {
 		return fmt.Errorf("failed to create promptly config directory: %w", err)
 	}
 
-	underlyingShell, err := selectStarshipShell()
+	promptlyPath := filepath.Join(promptlyDir, "promptly.ps1")
+
+	content := theme.Contents[ShellPwsh]
+	installed := installedContent(theme, ShellPwsh)
+
+	if theme.IsCustom {
+		configThemePath := filepath.Join(promptlyDir, theme.Name+".promptly.ps1")
+		if err := tx.WriteFile(configThemePath, []byte(content), 0644); err != nil {
+			return err
+		}
+		if installed == content {
+			installed = customStub(ShellPwsh, paths.Home, configThemePath)
+		}
+	}
+
+	if err := tx.WriteFile(promptlyPath, []byte(installed), 0644); err != nil {
+		return err
+	}
+
+	return updateRCFile(tx, paths.PwshProfile, []string{fmt.Sprintf(". %s", shellPath(ShellPwsh, paths.Home, promptlyPath))})
+}
+
+// installNu writes the theme, which sets the PROMPT_COMMAND closure, and
+// sources it from config.nu.
+func installNu(tx *Transaction, theme Theme) error {
+	paths, err := resolvePaths()
 	if err != nil {
-		return fmt.Errorf("failed to select shell: %w", err)
+		return err
 	}
 
-	var tomlPath string
+	promptlyDir := paths.Config
+	if err := tx.MkdirAll(promptlyDir, 0755); err != nil {
+		return fmt.Errorf("failed to create promptly config directory: %w", err)
+	}
+
+	promptlyPath := filepath.Join(promptlyDir, "promptly.nu")
+
+	content := theme.Contents[ShellNu]
+	installed := installedContent(theme, ShellNu)
+
 	if theme.IsCustom {
-		tomlPath = filepath.Join(promptlyDir, theme.Name+".promptly.toml")
-		if err := os.WriteFile(tomlPath, []byte(theme.Contents[ShellStarship]), 0644); err != nil {
+		configThemePath := filepath.Join(promptlyDir, theme.Name+".promptly.nu")
+		if err := tx.WriteFile(configThemePath, []byte(content), 0644); err != nil {
 			return err
 		}
-	} else {
-		tomlPath = filepath.Join(promptlyDir, "promptly.toml")
-		if err := os.WriteFile(tomlPath, []byte(theme.Contents[ShellStarship]), 0644); err != nil {
-			return err
+		if installed == content {
+			installed = customStub(ShellNu, paths.Home, configThemePath)
+		}
+	}
+
+	if err := tx.WriteFile(promptlyPath, []byte(installed), 0644); err != nil {
+		return err
+	}
+
+	return updateRCFile(tx, paths.NuConfig, []string{fmt.Sprintf("source %s", nuPath(paths.Home, promptlyPath))})
+}
+
+func installStarship(tx *Transaction, theme Theme, underlyingShell string) error {
+	paths, err := resolvePaths()
+	if err != nil {
+		return err
+	}
+
+	promptlyDir := paths.Config
+
+	// A custom theme's starship config is used from the config directory,
+	// unless --no-icons has to replace its glyphs in an installed copy.
+	content := theme.Contents[ShellStarship]
+	installed := installedContent(theme, ShellStarship)
+	tomlPath := filepath.Join(promptlyDir, "promptly.toml")
+	customPath := ""
+	if theme.IsCustom {
+		customPath = filepath.Join(promptlyDir, theme.Name+".promptly.toml")
+		if installed == content {
+			tomlPath = customPath
 		}
 	}
 
 	type rcEntry struct {
 		path      string
 		configCmd string
-		initCmd   string
+		initCmds  []string
 	}
 
 	var entry rcEntry
 	switch underlyingShell {
 	case "zsh":
 		entry = rcEntry{
-			path:      filepath.Join(homeDir, ".zshrc"),
-			configCmd: fmt.Sprintf("export STARSHIP_CONFIG=%s", tomlPath),
-			initCmd:   `eval "$(starship init zsh)"`,
+			path:      paths.RCFile("zsh"),
+			configCmd: fmt.Sprintf("export STARSHIP_CONFIG=%s", shellPath(ShellZsh, paths.Home, tomlPath)),
+			initCmds:  []string{`eval "$(starship init zsh)"`},
 		}
 	case "bash":
 		entry = rcEntry{
-			path:      filepath.Join(homeDir, ".bashrc"),
-			configCmd: fmt.Sprintf("export STARSHIP_CONFIG=%s", tomlPath),
-			initCmd:   `eval "$(starship init bash)"`,
+			path:      paths.RCFile("bash"),
+			configCmd: fmt.Sprintf("export STARSHIP_CONFIG=%s", shellPath(ShellBash, paths.Home, tomlPath)),
+			initCmds:  []string{`eval "$(starship init bash)"`},
 		}
 	case "fish":
 		entry = rcEntry{
-			path:      filepath.Join(homeDir, ".config", "fish", "config.fish"),
-			configCmd: fmt.Sprintf("set -x STARSHIP_CONFIG %s", tomlPath),
-			initCmd:   "starship init fish | source",
+			path:      paths.RCFile("fish"),
+			configCmd: fmt.Sprintf("set -x STARSHIP_CONFIG %s", shellPath(ShellFish, paths.Home, tomlPath)),
+			initCmds:  []string{"starship init fish | source"},
 		}
+	case "pwsh":
+		entry = rcEntry{
+			path:      paths.RCFile("pwsh"),
+			configCmd: fmt.Sprintf("$env:STARSHIP_CONFIG = %s", shellPath(ShellPwsh, paths.Home, tomlPath)),
+			initCmds:  []string{"Invoke-Expression (&starship init powershell)"},
+		}
+	case "nu":
+		// Nushell can't eval the init script, so it is saved into the
+		// vendor autoload directory that nu loads after config.nu.
+		entry = rcEntry{
+			path:      paths.RCFile("nu"),
+			configCmd: fmt.Sprintf("$env.STARSHIP_CONFIG = (%s | path expand)", nuPath(paths.Home, tomlPath)),
+			initCmds: []string{
+				`mkdir ($nu.data-dir | path join "vendor/autoload")`,
+				fmt.Sprintf(`starship init nu | save -f ($nu.data-dir | path join %q)`, nuStarshipAutoload),
+			},
+		}
+	default:
+		return fmt.Errorf("unsupported shell for starship: %q", underlyingShell)
 	}
 
-	if _, err := os.Stat(entry.path); os.IsNotExist(err) {
-		return fmt.Errorf("could not find rc file at %s", entry.path)
+	if err := tx.MkdirAll(promptlyDir, 0755); err != nil {
+		return fmt.Errorf("failed to create promptly config directory: %w", err)
 	}
-
-	if err := updateRCFile(entry.path, entry.configCmd, "# Promptly - Starship config"); err != nil {
-		return err
+	if customPath != "" {
+		if err := tx.WriteFile(customPath, []byte(content), 0644); err != nil {
+			return err
+		}
 	}
-	if err := updateRCFile(entry.path, entry.initCmd, "# Promptly - Starship init"); err != nil {
-		return err
+	if tomlPath != customPath {
+		if err := tx.WriteFile(tomlPath, []byte(installed), 0644); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return updateRCFile(tx, entry.path, append([]string{entry.configCmd}, entry.initCmds...))
 }
 
-// updateRCFile appends cmd to rcPath if not already present.
-func updateRCFile(rcPath, cmd, comment string) error {
-	content, _ := os.ReadFile(rcPath)
-	if strings.Contains(string(content), cmd) {
-		return nil
+// nativeTheme returns theme with its variant for shell replaced by a stub
+// that loads promptly init. The stub is installed like a built-in theme;
+// a custom theme's spec stays in the config directory, where promptly prompt
+// reads it.
+func nativeTheme(theme Theme, shell ShellTarget, opts InstallOptions) Theme {
+	theme.Contents = maps.Clone(theme.Contents)
+	theme.Contents[shell] = nativeStub(shell, RenderOptions{Theme: theme.Name, Palette: opts.Palette})
+	theme.IsCustom = false
+	return theme
+}
+
+// customStub returns the file installed for shell that sources the custom
+// theme at themePath, which stays in the config directory so it can be edited.
+func customStub(shell ShellTarget, homeDir, themePath string) string {
+	switch shell {
+	case ShellPwsh:
+		return fmt.Sprintf("# Promptly theme sourcing\n. %s\n", shellPath(ShellPwsh, homeDir, themePath))
+	case ShellNu:
+		return fmt.Sprintf("# Promptly theme sourcing\nsource %s\n", nuPath(homeDir, themePath))
 	}
+	return fmt.Sprintf("# Promptly theme sourcing\nsource %s\n", shellPath(shell, homeDir, themePath))
+}
 
-	file, err := os.OpenFile(rcPath, os.O_RDWR|os.O_CREATE, 0644)
+// updateRCFile writes lines into the promptly block of rcPath, replacing the
+// block from a previous install in place or appending a new one.
+func updateRCFile(tx *Transaction, rcPath string, lines []string) error {
+	rc, err := readRCFile(rcPath)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-
-	if len(lines) > 0 && lines[len(lines)-1] != "" {
-		lines = append(lines, "")
-	}
-	lines = append(lines, comment, cmd)
-
-	file.Seek(0, 0)
-	file.Truncate(0)
-	for _, line := range lines {
-		file.WriteString(line + "\n")
+	if err := rc.SetBlock(lines); err != nil {
+		return err
 	}
-	return nil
+	return rc.Save(tx)
 }
 
 // ─────────────────────────────────────────────────────────────
@@ -557,69 +980,48 @@ func updateRCFile(rcPath, cmd, comment string) error {
 // ─────────────────────────────────────────────────────────────
 
 func loadCustomThemes() ([]Theme, error) {
-	homeDir, err := os.UserHomeDir()
+	paths, err := resolvePaths()
 	if err != nil {
 		return nil, err
 	}
 
-	configDir := filepath.Join(homeDir, ".config", "promptly")
+	configDir := paths.Config
 	if _, err := os.Stat(configDir); os.IsNotExist(err) {
 		return []Theme{}, nil
 	}
 
-	files, err := os.ReadDir(configDir)
+	// A broken file only costs a custom theme that variant, its description
+	// or its preview; the rest of the theme still loads.
+	themeMap, err := readThemes(os.DirFS(configDir), Theme{
+		Description: "Custom theme",
+		Preview:     "Preview not available for custom themes",
+		IsCustom:    true,
+		SourcePath:  configDir,
+	}, false)
 	if err != nil {
 		return nil, err
 	}
 
-	themeMap := make(map[string]*Theme)
-
-	type shellFile struct {
-		suffix string
-		shell  ShellTarget
-	}
-	shellFiles := []shellFile{
-		{".promptly.zsh", ShellZsh},
-		{".promptly.fish", ShellFish},
-		{".promptly.toml", ShellStarship},
-	}
-
-	for _, file := range files {
-		for _, sf := range shellFiles {
-			if !strings.HasSuffix(file.Name(), sf.suffix) {
-				continue
-			}
-			filePath := filepath.Join(configDir, file.Name())
-			content, err := os.ReadFile(filePath)
-			if err != nil {
-				continue
-			}
-			name := strings.TrimSuffix(file.Name(), sf.suffix)
-			if _, ok := themeMap[name]; !ok {
-				themeMap[name] = &Theme{
-					Name:        name,
-					Description: "Custom theme",
-					Contents:    make(map[ShellTarget]string),
-					Preview:     generatePreview(name),
-					IsCustom:    true,
-					SourcePath:  filePath,
-				}
-			}
-			themeMap[name].Contents[sf.shell] = string(content)
-		}
-	}
-
 	var themes []Theme
 	for _, t := range themeMap {
 		themes = append(themes, *t)
 	}
+	sortThemes(themes)
 	return themes, nil
 }
 
+// sortThemes orders themes by name so menus and listings are stable
+// regardless of map iteration order.
+func sortThemes(themes []Theme) {
+	sort.Slice(themes, func(i, j int) bool {
+		return themes[i].Name < themes[j].Name
+	})
+}
+
 func selectCustomThemeBase(allThemes []Theme, shell ShellTarget) (Theme, error) {
 	var baseThemes []Theme
 	for _, t := range allThemes {
-		if !t.IsCustom && t.Name != "Create Custom" {
+		if !t.IsCustom {
 			baseThemes = append(baseThemes, t)
 		}
 	}
@@ -629,9 +1031,7 @@ func selectCustomThemeBase(allThemes []Theme, shell ShellTarget) (Theme, error)
 		Active:   "▸ {{ .Name | cyan }} - {{ .Description }}",
 		Inactive: "  {{ .Name | cyan }} - {{ .Description }}",
 		Selected: "{{ .Name | red | cyan }}",
-		Details: `
---------- Preview ---------
-{{ .Preview }}`,
+		Details:  themeDetails,
 	}
 
 	prompt := promptui.Select{
@@ -646,59 +1046,131 @@ func selectCustomThemeBase(allThemes []Theme, shell ShellTarget) (Theme, error)
 		return Theme{}, err
 	}
 
-	return createCustomTheme(baseThemes[i], shell)
-}
-
-func createCustomTheme(baseTheme Theme, shell ShellTarget) (Theme, error) {
-	homeDir, err := os.UserHomeDir()
+	// allThemes only has the themes for this shell, but the name must not
+	// clash with a custom theme for any shell.
+	all, err := loadThemes()
 	if err != nil {
-		return Theme{}, fmt.Errorf("failed to get home directory: %w", err)
+		return Theme{}, err
+	}
+	name, err := promptCustomThemeName(all)
+	if err != nil {
+		return Theme{}, err
 	}
 
-	configDir := filepath.Join(homeDir, ".config", "promptly")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return Theme{}, fmt.Errorf("failed to create config directory: %w", err)
+	custom, err := createCustomTheme(baseThemes[i], shell, name)
+	if err != nil || custom.Spec == nil {
+		return custom, err
 	}
 
-	custom := Theme{
-		Name:        "custom",
-		Description: "Custom theme based on " + baseTheme.Name,
-		Contents:    make(map[ShellTarget]string),
-		Preview:     generatePreview("custom"),
-		IsCustom:    true,
+	edited, err := editCustomTheme(custom)
+	if errors.Is(err, errDiscarded) {
+		return custom, nil
 	}
+	return edited, err
+}
 
-	type shellFile struct {
-		shell  ShellTarget
-		suffix string
+// themeNamePattern is what a theme name may look like: it becomes part of
+// file names, so no path separators, spaces or leading dots.
+var themeNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// validateThemeName checks that name can be used for a new custom theme. It
+// may not clash with a built-in theme; clashing with a custom theme is left
+// to the caller to confirm.
+func validateThemeName(name string, themes []Theme) error {
+	if !themeNamePattern.MatchString(name) {
+		return errors.New("use letters, digits, '-' and '_' only, starting with a letter or digit")
 	}
-	shellFiles := []shellFile{
-		{ShellZsh, ".promptly.zsh"},
-		{ShellFish, ".promptly.fish"},
-		{ShellStarship, ".promptly.toml"},
+	for _, t := range themes {
+		if t.Name == name && !t.IsCustom {
+			return fmt.Errorf("%q is a built-in theme", name)
+		}
 	}
+	return nil
+}
 
-	for _, sf := range shellFiles {
-		if sf.shell != shell {
-			continue
+// promptCustomThemeName asks for the name of a new custom theme until it gets
+// a valid one. Reusing the name of a custom theme overwrites it, so that has
+// to be confirmed.
+func promptCustomThemeName(themes []Theme) (string, error) {
+	for {
+		prompt := promptui.Prompt{
+			Label: "Name your custom theme",
+			Validate: func(name string) error {
+				return validateThemeName(name, themes)
+			},
+		}
+		name, err := prompt.Run()
+		if err != nil {
+			return "", err
 		}
-		content, ok := baseTheme.Contents[sf.shell]
-		if !ok {
-			return Theme{}, fmt.Errorf("base theme %q has no %s variant", baseTheme.Name, shell)
+
+		if _, exists := findCustomTheme(themes, name); !exists {
+			return name, nil
 		}
-		path := filepath.Join(configDir, "custom"+sf.suffix)
-		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
-			return Theme{}, fmt.Errorf("failed to create custom %s theme file: %w", sf.shell, err)
+		confirm := promptui.Prompt{
+			Label:     fmt.Sprintf("A custom theme named %q already exists. Overwrite it", name),
+			IsConfirm: true,
+		}
+		if _, err := confirm.Run(); err == nil {
+			return name, nil
+		} else if !errors.Is(err, promptui.ErrAbort) {
+			return "", err
+		}
+	}
+}
+
+// findCustomTheme looks up the custom theme called name.
+func findCustomTheme(themes []Theme, name string) (Theme, bool) {
+	for _, t := range themes {
+		if t.IsCustom && t.Name == name && !isMenuEntry(t) {
+			return t, true
 		}
-		custom.Contents[sf.shell] = content
-		custom.SourcePath = path
-		color.Green("✓ Custom %s theme created at %s", sf.shell, path)
 	}
+	return Theme{}, false
+}
 
-	if len(custom.Contents) == 0 {
-		return Theme{}, fmt.Errorf("base theme %q has no supported shell variants", baseTheme.Name)
+func createCustomTheme(baseTheme Theme, shell ShellTarget, name string) (Theme, error) {
+	paths, err := resolvePaths()
+	if err != nil {
+		return Theme{}, err
 	}
 
-	fmt.Println("You can now edit this file to customize your theme.")
+	configDir := paths.Config
+
+	custom := Theme{
+		Name:        name,
+		Description: "Custom theme based on " + baseTheme.Name,
+		Contents:    make(map[ShellTarget]string),
+		Preview:     baseTheme.Preview,
+		IsCustom:    true,
+		Spec:        baseTheme.Spec,
+	}
+
+	// The custom theme starts out looking like its base in the selector.
+	meta := baseTheme.Meta
+	meta.Name = custom.Name
+	meta.Description = custom.Description
+	meta.Author = ""
+	meta.Version = ""
+	meta.Base = baseTheme.Name
+	meta.Shells = []string{string(shell)}
+	custom.Meta = meta
+
+	content, ok := baseTheme.Contents[shell]
+	if !ok {
+		return Theme{}, fmt.Errorf("base theme %q has no %s variant", baseTheme.Name, shell)
+	}
+	// The files themselves are written by the install transaction. A theme
+	// with a spec keeps it, so it can be edited and compiled for other
+	// shells later.
+	custom.SourcePath = filepath.Join(configDir, name+themeSuffixes[shell])
+	if baseTheme.Spec != nil {
+		if isGenerated(content) {
+			content = compileTheme(name, *baseTheme.Spec)[shell]
+		}
+		custom.SourcePath = filepath.Join(configDir, name+specSuffix)
+		custom.SaveSpec = true
+	}
+	custom.Contents[shell] = content
 	return custom, nil
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fatih/color"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Theme manifests
+// ─────────────────────────────────────────────────────────────
+
+// metaSuffix is the file suffix of a theme manifest, which sits next to the
+// theme's shell files: melange.promptly.meta.toml describes
+// melange.promptly.zsh, melange.promptly.fish and so on.
+const metaSuffix = ".promptly.meta.toml"
+
+// ThemeMeta is a theme manifest: everything the selector shows about a theme
+// besides the theme itself.
+type ThemeMeta struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description"`
+	Author      string `toml:"author,omitempty"`
+	Version     string `toml:"version,omitempty"`
+	// Base is the theme a custom theme was created from.
+	Base string `toml:"base,omitempty"`
+	// Font is "nerd" when the theme uses Nerd Font glyphs.
+	Font string `toml:"font,omitempty"`
+	// Shells lists the targets the theme is written for.
+	Shells  []string      `toml:"shells,omitempty"`
+	Preview PreviewSample `toml:"preview"`
+}
+
+// PreviewSample is the sample prompt shown in the selector, as lines of
+// colored text segments.
+type PreviewSample struct {
+	Lines [][]PreviewSegment `toml:"lines"`
+}
+
+// PreviewSegment is a run of text in one color: a role from the theme's spec
+// or palette, a #rrggbb hex value, a 256-color index or one of the basic
+// terminal color names (black, red, green, yellow, blue, magenta, cyan,
+// white). Without a color the text is printed as is.
+type PreviewSegment struct {
+	Text  string `toml:"text"`
+	Color string `toml:"color,omitempty"`
+}
+
+// parseThemeMeta decodes a manifest. Unknown keys are an error so a typo
+// doesn't silently drop a field.
+func parseThemeMeta(data []byte) (ThemeMeta, error) {
+	var meta ThemeMeta
+	md, err := toml.Decode(string(data), &meta)
+	if err != nil {
+		return ThemeMeta{}, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return ThemeMeta{}, fmt.Errorf("unknown key %q", undecoded[0].String())
+	}
+	return meta, nil
+}
+
+// encodeThemeMeta renders meta as a manifest file.
+func encodeThemeMeta(meta ThemeMeta) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(meta); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// applyMeta fills in the parts of theme that come from its manifest. The
+// preview is drawn in the colors of the theme's spec, if it has one.
+func applyMeta(theme *Theme, meta ThemeMeta) {
+	if noIcons {
+		meta.Font = ""
+	}
+	theme.Meta = meta
+	if meta.Description != "" {
+		theme.Description = meta.Description
+	}
+	var roles map[string]string
+	if theme.Spec != nil {
+		roles = theme.Spec.Colors
+	}
+	if preview := renderPreview(meta.Preview, roles); preview != "" {
+		theme.Preview = preview
+	}
+}
+
+var previewColors = map[string]color.Attribute{
+	"black":   color.FgBlack,
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+}
+
+// renderPreview renders a preview sample with terminal colors, looking
+// segment colors up in roles first.
+func renderPreview(sample PreviewSample, roles map[string]string) string {
+	lines := make([]string, len(sample.Lines))
+	for i, segments := range sample.Lines {
+		var b strings.Builder
+		for _, s := range segments {
+			c := s.Color
+			if value, ok := roles[c]; ok {
+				c = value
+			}
+			text := s.Text
+			if noIcons {
+				text = textIcons(text)
+			}
+			b.WriteString(colorize(c, text))
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mel paints text in a #rrggbb color, downsampled to the terminal's color
+// depth.
+func mel(hex, text string) string {
+	c, err := parseColor(hex)
+	if err != nil {
+		return text
+	}
+	return paint(c, text)
+}
+
+// paint paints text in c, downsampled to the terminal's color depth.
+func paint(c Color, text string) string {
+	return fmt.Sprintf("\033[%sm%s\033[0m", c.downsample(colorDepth()).SGR(), text)
+}
+
+// colorize paints text in a preview segment color.
+func colorize(value, text string) string {
+	c, err := parseColor(value)
+	switch {
+	case err != nil:
+		return text
+	case c.Name != "":
+		return color.New(previewColors[c.Name]).Sprint(text)
+	default:
+		return paint(c, text)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Palettes
+// ─────────────────────────────────────────────────────────────
+
+// paletteSuffix is the file suffix of a palette.
+const paletteSuffix = ".promptly.palette.toml"
+
+// paletteRoles are the color roles a palette defines. Themes draw with these
+// names, so any theme built from a spec can be recolored with any palette.
+var paletteRoles = []string{"dir", "muted", "cyan", "purple", "green", "yellow", "red", "accent"}
+
+// Palette is a named set of colors for the palette roles.
+type Palette struct {
+	Name        string            `toml:"-"`
+	Description string            `toml:"description"`
+	Colors      map[string]string `toml:"colors"`
+}
+
+// parsePalette decodes and validates a palette. Every role must be set, and
+// only those roles.
+func parsePalette(name string, data []byte) (Palette, error) {
+	var p Palette
+	md, err := toml.Decode(string(data), &p)
+	if err != nil {
+		return Palette{}, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return Palette{}, fmt.Errorf("unknown key %q", undecoded[0].String())
+	}
+	p.Name = name
+
+	for role, value := range p.Colors {
+		if !slices.Contains(paletteRoles, role) {
+			return Palette{}, fmt.Errorf("colors.%s: unknown role (expected %s)", role, strings.Join(paletteRoles, ", "))
+		}
+		if _, err := parseColor(value); err != nil {
+			return Palette{}, fmt.Errorf("colors.%s: %w", role, err)
+		}
+	}
+	for _, role := range paletteRoles {
+		if _, ok := p.Colors[role]; !ok {
+			return Palette{}, fmt.Errorf("colors.%s is missing", role)
+		}
+	}
+	return p, nil
+}
+
+// loadPalettes returns the built-in palettes followed by the custom ones in
+// the config directory. A broken custom palette is skipped.
+func loadPalettes() ([]Palette, error) {
+	palettes, err := readPalettes(themeFiles, true)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := resolvePaths()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(paths.Config); err == nil {
+		custom, err := readPalettes(os.DirFS(paths.Config), false)
+		if err != nil {
+			return nil, err
+		}
+		palettes = append(palettes, custom...)
+	}
+	return palettes, nil
+}
+
+func readPalettes(fsys fs.FS, strict bool) ([]Palette, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var palettes []Palette
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), paletteSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err == nil {
+			var p Palette
+			if p, err = parsePalette(name, data); err == nil {
+				palettes = append(palettes, p)
+				continue
+			}
+		}
+		if strict {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+	}
+	sort.Slice(palettes, func(i, j int) bool {
+		return palettes[i].Name < palettes[j].Name
+	})
+	return palettes, nil
+}
+
+// findPalette looks a palette up by name.
+func findPalette(palettes []Palette, name string) (Palette, error) {
+	names := make([]string, len(palettes))
+	for i, p := range palettes {
+		if p.Name == name {
+			return p, nil
+		}
+		names[i] = p.Name
+	}
+	return Palette{}, fmt.Errorf("palette %q not found (available: %s)", name, strings.Join(names, ", "))
+}
+
+// withPalette returns s with the palette's roles in place of its own colors.
+func (s ThemeSpec) withPalette(p Palette) ThemeSpec {
+	s.Colors = maps.Clone(s.Colors)
+	if s.Colors == nil {
+		s.Colors = make(map[string]string)
+	}
+	maps.Copy(s.Colors, p.Colors)
+	return s
+}
+
+// withPalette returns theme recolored with p: its spec is compiled again with
+// the palette's roles, and its preview rendered in them. Hand-written shell
+// variants are kept as they are.
+func (t Theme) withPalette(p Palette) (Theme, error) {
+	if t.Spec == nil {
+		return Theme{}, fmt.Errorf("theme %q has no theme spec, so it can't be recolored with a palette", t.Name)
+	}
+
+	spec := t.Spec.withPalette(p)
+	t.Spec = &spec
+
+	contents := make(map[ShellTarget]string, len(t.Contents))
+	for shell, content := range t.Contents {
+		contents[shell] = content
+	}
+	for shell, content := range compileTheme(t.Name, spec) {
+		if current, ok := contents[shell]; ok && isGenerated(current) {
+			contents[shell] = content
+		}
+	}
+	t.Contents = contents
+
+	if preview := renderPreview(t.Meta.Preview, spec.Colors); preview != "" {
+		t.Preview = preview
+	}
+	return t, nil
+}
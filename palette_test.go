@@ -0,0 +1,112 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const melange = `description = "Warm"
+
+[colors]
+dir = "#C1A78E"
+muted = "#867462"
+cyan = "#89B3B6"
+purple = "#A3A9CE"
+green = "#85B695"
+yellow = "#EBC06D"
+red = "#D47766"
+accent = "#CF9BC2"
+`
+
+func TestParsePalette(t *testing.T) {
+	tests := []struct {
+		name, data string
+		wantErr    string // part of the error, or "" for none
+	}{
+		{"complete", melange, ""},
+		{"missing role", strings.Replace(melange, "accent = \"#CF9BC2\"\n", "", 1), "colors.accent is missing"},
+		{"unknown role", melange + "orange = \"#FF8800\"\n", "colors.orange: unknown role"},
+		{"bad color", strings.Replace(melange, "#D47766", "terracotta", 1), "colors.red"},
+		{"unknown key", "name = \"x\"\n" + melange, `unknown key "name"`},
+	}
+	for _, tt := range tests {
+		p, err := parsePalette("melange", []byte(tt.data))
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErr == "" && (p.Name != "melange" || p.Colors["dir"] != "#C1A78E"):
+			t.Errorf("%s: got %+v", tt.name, p)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestThemeWithPalette checks that recoloring compiles the generated
+// variants again and leaves hand-written ones alone.
+func TestThemeWithPalette(t *testing.T) {
+	palette, err := parsePalette("melange", []byte(melange))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := builtinSpecs(t)["default"]
+	const handWritten = "# my own fish prompt\n"
+	contents := compileTheme("default", spec)
+	contents[ShellFish] = handWritten
+	delete(contents, ShellNu)
+	theme := Theme{Name: "default", Contents: contents, Spec: &spec}
+
+	recolored, err := theme.withPalette(palette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := compileTheme("default", spec.withPalette(palette))
+	for shell, content := range recolored.Contents {
+		switch {
+		case shell == ShellFish && content != handWritten:
+			t.Errorf("the hand-written fish variant was replaced")
+		case shell != ShellFish && content != want[shell]:
+			t.Errorf("%s wasn't compiled with the palette", shell)
+		case shell != ShellFish && content == contents[shell]:
+			t.Errorf("%s still has the theme's own colors", shell)
+		}
+	}
+	if _, ok := recolored.Contents[ShellNu]; ok {
+		t.Errorf("a variant the theme doesn't have was added")
+	}
+	if theme.Contents[ShellZsh] != compileTheme("default", spec)[ShellZsh] {
+		t.Errorf("the original theme was changed")
+	}
+
+	if _, err := (Theme{Name: "plain"}).withPalette(palette); err == nil {
+		t.Errorf("a theme without a spec was recolored")
+	}
+}
+
+// TestBuiltinColorsArePaletteRoles checks that every color in the built-in
+// themes and their previews is a palette role, so a palette recolors all of
+// it.
+func TestBuiltinColorsArePaletteRoles(t *testing.T) {
+	for name, spec := range builtinSpecs(t) {
+		for i, seg := range spec.Segments {
+			for _, c := range []string{seg.Color, seg.ErrorColor} {
+				if c != "" && !slices.Contains(paletteRoles, c) {
+					t.Errorf("%s: segment %d (%s) is colored %q, which isn't a palette role", name, i+1, seg.Type, c)
+				}
+			}
+		}
+
+		meta, err := parseThemeMeta([]byte(readString(t, name+metaSuffix)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range meta.Preview.Lines {
+			for _, seg := range line {
+				if seg.Color != "" && !slices.Contains(paletteRoles, seg.Color) {
+					t.Errorf("%s: the preview colors %q %q, which isn't a palette role", name, seg.Text, seg.Color)
+				}
+			}
+		}
+	}
+}
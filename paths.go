@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Install locations
+// ─────────────────────────────────────────────────────────────
+
+// homeOverride is set by the --home flag and takes precedence over
+// $PROMPTLY_HOME.
+var homeOverride string
+
+// Paths are the files and directories promptly reads and writes, resolved
+// the way each shell resolves them.
+type Paths struct {
+	Home string
+	// Config holds installed theme files, custom themes and backups:
+	// $XDG_CONFIG_HOME/promptly.
+	Config string
+	// ZshRC is $ZDOTDIR/.zshrc, where ZDOTDIR may also be set in ~/.zshenv.
+	ZshRC string
+	// ZshTheme is the file the zsh installer writes and sources from ZshRC.
+	ZshTheme string
+	BashRC   string
+	// BashTheme is the file the bash installer writes and sources from BashRC.
+	BashTheme string
+	// FishConfig is $XDG_CONFIG_HOME/fish/config.fish.
+	FishConfig string
+	// PwshProfile is PowerShell's $PROFILE on Linux and macOS:
+	// $XDG_CONFIG_HOME/powershell/Microsoft.PowerShell_profile.ps1.
+	PwshProfile string
+	// NuConfig is $XDG_CONFIG_HOME/nushell/config.nu.
+	NuConfig string
+	// NuStarshipInit is where the nu starship block saves starship's init
+	// script: $XDG_DATA_HOME/nushell/vendor/autoload/starship.nu.
+	NuStarshipInit string
+
+	// Origins explains how each non-default location was chosen, keyed by
+	// the same labels Describe uses.
+	Origins map[string]string
+}
+
+// targetHomeDir returns the home directory promptly installs into: --home,
+// then $PROMPTLY_HOME, then the current user's home. Pointing it somewhere
+// else renders a complete setup into that directory, e.g. for a container
+// image or a dotfiles checkout.
+func targetHomeDir() (string, error) {
+	for _, dir := range []string{homeOverride, os.Getenv("PROMPTLY_HOME")} {
+		if dir != "" {
+			return filepath.Abs(dir)
+		}
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return homeDir, nil
+}
+
+// nuStarshipAutoload is the file, relative to nu's data directory, that the
+// nu starship block saves starship's init script to.
+const nuStarshipAutoload = "vendor/autoload/starship.nu"
+
+// resolvePaths works out where every file promptly touches lives, honoring
+// $XDG_CONFIG_HOME, $XDG_DATA_HOME and $ZDOTDIR.
+func resolvePaths() (Paths, error) {
+	homeDir, err := targetHomeDir()
+	if err != nil {
+		return Paths{}, err
+	}
+
+	p := Paths{
+		Home:      homeDir,
+		ZshTheme:  filepath.Join(homeDir, ".promptly.zsh"),
+		BashRC:    filepath.Join(homeDir, ".bashrc"),
+		BashTheme: filepath.Join(homeDir, ".promptly.bash"),
+		Origins:   make(map[string]string),
+	}
+
+	configHome := filepath.Join(homeDir, ".config")
+	if dir := envPath(homeDir, "XDG_CONFIG_HOME"); dir != "" {
+		configHome = dir
+		p.Origins["config dir"] = "$XDG_CONFIG_HOME"
+		p.Origins["fish config"] = "$XDG_CONFIG_HOME"
+		p.Origins["pwsh profile"] = "$XDG_CONFIG_HOME"
+		p.Origins["nu config"] = "$XDG_CONFIG_HOME"
+	}
+	p.Config = filepath.Join(configHome, "promptly")
+	p.FishConfig = filepath.Join(configHome, "fish", "config.fish")
+	p.PwshProfile = filepath.Join(configHome, "powershell", "Microsoft.PowerShell_profile.ps1")
+	p.NuConfig = filepath.Join(configHome, "nushell", "config.nu")
+
+	dataHome := filepath.Join(homeDir, ".local", "share")
+	if dir := envPath(homeDir, "XDG_DATA_HOME"); dir != "" {
+		dataHome = dir
+		p.Origins["nu starship init"] = "$XDG_DATA_HOME"
+	}
+	p.NuStarshipInit = filepath.Join(dataHome, "nushell", filepath.FromSlash(nuStarshipAutoload))
+
+	zdotdir := homeDir
+	if dir := envPath(homeDir, "ZDOTDIR"); dir != "" {
+		zdotdir = dir
+		p.Origins["zshrc"] = "$ZDOTDIR"
+	} else if dir := zdotdirFromZshenv(homeDir, configHome); dir != "" {
+		zdotdir = dir
+		p.Origins["zshrc"] = "ZDOTDIR in ~/.zshenv"
+	}
+	p.ZshRC = filepath.Join(zdotdir, ".zshrc")
+
+	return p, nil
+}
+
+// Describe lists the resolved locations in display order.
+func (p Paths) Describe() [][2]string {
+	return [][2]string{
+		{"home", p.Home},
+		{"config dir", p.Config},
+		{"zshrc", p.ZshRC},
+		{"zsh theme", p.ZshTheme},
+		{"bashrc", p.BashRC},
+		{"bash theme", p.BashTheme},
+		{"fish config", p.FishConfig},
+		{"pwsh profile", p.PwshProfile},
+		{"nu config", p.NuConfig},
+		{"nu starship init", p.NuStarshipInit},
+	}
+}
+
+// rcLabels maps each shell to the Describe label of its rc file.
+var rcLabels = map[string]string{
+	"zsh":  "zshrc",
+	"bash": "bashrc",
+	"fish": "fish config",
+	"pwsh": "pwsh profile",
+	"nu":   "nu config",
+}
+
+// RCFile returns the rc file of a shell by name: zsh, bash, fish, pwsh or nu.
+func (p Paths) RCFile(shell string) string {
+	switch shell {
+	case "zsh":
+		return p.ZshRC
+	case "bash":
+		return p.BashRC
+	case "fish":
+		return p.FishConfig
+	case "pwsh":
+		return p.PwshProfile
+	case "nu":
+		return p.NuConfig
+	}
+	return ""
+}
+
+// RCFiles returns every rc file promptly may have edited.
+func (p Paths) RCFiles() []string {
+	return []string{p.ZshRC, p.BashRC, p.FishConfig, p.PwshProfile, p.NuConfig}
+}
+
+// envPath returns the absolute directory in the environment variable name.
+// When installing into an alternate home, directories inside the real home
+// are moved into it and anything else is ignored, so the environment of the
+// user running promptly never leaks outside the rendered tree.
+func envPath(homeDir, name string) string {
+	dir := os.Getenv(name)
+	if dir == "" || !filepath.IsAbs(dir) {
+		// The XDG spec says relative paths are invalid and must be ignored.
+		return ""
+	}
+	return rebase(homeDir, filepath.Clean(dir))
+}
+
+// rebase maps dir from the real home directory into homeDir. It returns dir
+// unchanged when homeDir is the real home, and "" when dir lies outside it.
+func rebase(homeDir, dir string) string {
+	realHome, err := os.UserHomeDir()
+	if err != nil || realHome == homeDir {
+		return dir
+	}
+	rel, err := filepath.Rel(realHome, dir)
+	if err != nil || isOutside(rel) {
+		return ""
+	}
+	return filepath.Join(homeDir, rel)
+}
+
+var zdotdirAssign = regexp.MustCompile(`^\s*(?:export\s+)?ZDOTDIR=(.+?)\s*(?:#.*)?$`)
+
+// zdotdirFromZshenv returns the ZDOTDIR set in ~/.zshenv, which zsh reads
+// before looking for .zshrc. Only plain assignments built from ~, $HOME and
+// $XDG_CONFIG_HOME are understood.
+func zdotdirFromZshenv(homeDir, configHome string) string {
+	f, err := os.Open(filepath.Join(homeDir, ".zshenv"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var value string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		if m := zdotdirAssign.FindStringSubmatch(scanner.Text()); m != nil {
+			value = m[1]
+		}
+	}
+	if value == "" {
+		return ""
+	}
+
+	value = strings.Trim(value, `"'`)
+	replacer := strings.NewReplacer(
+		"${XDG_CONFIG_HOME:-$HOME/.config}", configHome,
+		"${XDG_CONFIG_HOME:-${HOME}/.config}", configHome,
+		"${XDG_CONFIG_HOME}", configHome,
+		"$XDG_CONFIG_HOME", configHome,
+		"${HOME}", homeDir,
+		"$HOME", homeDir,
+	)
+	if strings.HasPrefix(value, "~/") {
+		value = filepath.Join(homeDir, value[2:])
+	}
+	value = replacer.Replace(value)
+	if strings.ContainsAny(value, "$`") || !filepath.IsAbs(value) {
+		return ""
+	}
+	return filepath.Clean(value)
+}
+
+// shellPathEscapers escape the text of a double-quoted string in each shell
+// shellPath writes for. fish has no backtick substitution, and pwsh escapes
+// with a backtick instead of a backslash.
+var shellPathEscapers = map[ShellTarget]*strings.Replacer{
+	ShellZsh:  strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`"),
+	ShellBash: strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`"),
+	ShellFish: strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`),
+	ShellPwsh: strings.NewReplacer("`", "``", `"`, "`\"", `$`, "`$"),
+}
+
+// shellPath quotes path for use in a zsh, bash, fish or pwsh rc file. Paths
+// inside homeDir are written relative to $HOME, so the rc files and theme
+// stubs keep working when the home directory was rendered somewhere else and
+// copied into place.
+func shellPath(shell ShellTarget, homeDir, path string) string {
+	escape := shellPathEscapers[shell].Replace
+	rel, err := filepath.Rel(homeDir, path)
+	if err != nil || isOutside(rel) {
+		return `"` + escape(path) + `"`
+	}
+	return `"$HOME/` + escape(filepath.ToSlash(rel)) + `"`
+}
+
+// nuPath quotes path for use in Nushell, which doesn't expand variables in
+// strings. Paths inside homeDir are written as ~/..., for the same reason
+// shellPath uses $HOME. Only backtick strings expand ~ and they can't
+// escape a backtick, so a path with one is written in full in double quotes.
+func nuPath(homeDir, path string) string {
+	if strings.Contains(path, "`") {
+		return nuQuote(path)
+	}
+	rel, err := filepath.Rel(homeDir, path)
+	if err != nil || isOutside(rel) {
+		return "`" + path + "`"
+	}
+	return "`~/" + filepath.ToSlash(rel) + "`"
+}
+
+// displayPath shortens a path inside homeDir to ~/... for messages.
+func displayPath(homeDir, path string) string {
+	rel, err := filepath.Rel(homeDir, path)
+	if err != nil || isOutside(rel) {
+		return path
+	}
+	return "~/" + filepath.ToSlash(rel)
+}
+
+// isOutside reports whether a filepath.Rel result leaves its base directory.
+func isOutside(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// awkwardDir is a directory name that breaks a double-quoted path unless its
+// characters are escaped.
+const awkwardDir = "a\"b$HOME`touch pwned`\\c"
+
+func TestShellPathBash(t *testing.T) {
+	requireShell(t, "bash")
+	home := t.TempDir()
+	outside := t.TempDir()
+	for _, dir := range []string{filepath.Join(home, awkwardDir), filepath.Join(outside, awkwardDir)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, "theme.bash")
+		if err := os.WriteFile(path, []byte("echo -n sourced\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd := exec.Command("bash", "-c", "source "+shellPath(ShellBash, home, path))
+		cmd.Dir = outside
+		cmd.Env = append(os.Environ(), "HOME="+home)
+		out, err := cmd.CombinedOutput()
+		if err != nil || string(out) != "sourced" {
+			t.Errorf("%s: %v %q", path, err, out)
+		}
+		if _, err := os.Stat(filepath.Join(outside, "pwned")); err == nil {
+			t.Fatalf("%s ran a command", path)
+		}
+	}
+}
+
+func TestShellPath(t *testing.T) {
+	home := "/home/" + awkwardDir
+	tests := []struct {
+		shell      ShellTarget
+		path, want string
+	}{
+		{ShellZsh, home + "/.promptly.zsh", `"$HOME/.promptly.zsh"`},
+		{ShellZsh, "/opt/" + awkwardDir, "\"/opt/a\\\"b\\$HOME\\`touch pwned\\`\\\\c\""},
+		{ShellFish, "/opt/" + awkwardDir, "\"/opt/a\\\"b\\$HOME`touch pwned`\\\\c\""},
+		{ShellPwsh, "/opt/" + awkwardDir, "\"/opt/a`\"b`$HOME``touch pwned``\\c\""},
+		{ShellPwsh, home + "/x/" + awkwardDir, "\"$HOME/x/a`\"b`$HOME``touch pwned``\\c\""},
+	}
+	for _, tt := range tests {
+		if got := shellPath(tt.shell, home, tt.path); got != tt.want {
+			t.Errorf("%s %s: got %s, want %s", tt.shell, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNuPath(t *testing.T) {
+	tests := []struct {
+		home, path, want string
+	}{
+		{"/home/u", "/home/u/.config/promptly/promptly.nu", "`~/.config/promptly/promptly.nu`"},
+		{"/home/u", "/opt/promptly.nu", "`/opt/promptly.nu`"},
+		{"/home/u", "/opt/" + awkwardDir, "\"/opt/a\\\"b$HOME`touch pwned`\\\\c\""},
+		{"/home/a`b", "/home/a`b/promptly.nu", "\"/home/a`b/promptly.nu\""},
+	}
+	for _, tt := range tests {
+		if got := nuPath(tt.home, tt.path); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Rc-file editing
+// ─────────────────────────────────────────────────────────────
+
+// Everything promptly adds to an rc file lives between these two markers, so
+// an install can replace it in place and uninstall can find it again.
+const (
+	rcBlockBegin = "# >>> promptly >>>"
+	rcBlockEnd   = "# <<< promptly <<<"
+	rcBlockNote  = "# Managed by promptly. Changes inside this block are overwritten on install."
+)
+
+// legacyRCComments are the comment lines older versions of promptly wrote
+// above each command they appended to an rc file.
+var legacyRCComments = []string{
+	"# Promptly - Custom shell prompt theme",
+	"# Promptly - Starship config",
+	"# Promptly - Starship init",
+}
+
+// RCFile is an rc file loaded for editing. Edits only ever touch promptly's
+// own lines; every other byte, including line endings and a missing final
+// newline, is written back exactly as it was read.
+type RCFile struct {
+	// Path is the rc file as the shell sees it.
+	Path string
+	// Target is Path with symlinks resolved. Writes go here so a symlinked
+	// rc file (common in dotfile repos) stays a symlink.
+	Target string
+
+	original []byte
+	content  string
+	mode     os.FileMode
+	exists   bool
+	newline  string
+}
+
+// readRCFile loads path for editing. A missing file is treated as empty.
+func readRCFile(path string) (*RCFile, error) {
+	target, err := resolveSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &RCFile{Path: path, Target: target, mode: 0644, newline: "\n"}
+
+	info, err := os.Stat(target)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return nil, err
+	}
+
+	f.original = data
+	f.content = string(data)
+	f.mode = info.Mode().Perm()
+	f.exists = true
+	if i := bytes.IndexByte(data, '\n'); i > 0 && data[i-1] == '\r' {
+		f.newline = "\r\n"
+	}
+	return f, nil
+}
+
+// resolveSymlinks follows path through any symlinks, including a dangling
+// final link whose target doesn't exist yet.
+func resolveSymlinks(path string) (string, error) {
+	for i := 0; i < 40; i++ {
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return path, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+		link, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(path), link)
+		}
+		path = link
+	}
+	return "", fmt.Errorf("too many levels of symbolic links: %s", path)
+}
+
+// Content returns the file's current, possibly edited, contents.
+func (f *RCFile) Content() string {
+	return f.content
+}
+
+// Changed reports whether any edit modified the file.
+func (f *RCFile) Changed() bool {
+	return f.content != string(f.original)
+}
+
+// SetBlock replaces the promptly block with one holding lines, or appends a
+// new block after a blank separator line. Entries left by older versions of
+// promptly are removed so they don't run twice.
+func (f *RCFile) SetBlock(lines []string) error {
+	f.content, _ = removeLegacyEntries(f.content)
+
+	updated, err := setManagedBlock(f.content, lines, f.newline)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.Path, err)
+	}
+	f.content = updated
+	return nil
+}
+
+// RemoveBlock deletes the promptly block and any entries left by older
+// versions of promptly. It reports whether anything was removed.
+func (f *RCFile) RemoveBlock() (bool, error) {
+	out, removedBlock, err := removeManagedBlock(f.content, f.newline)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", f.Path, err)
+	}
+	out, removedLegacy := removeLegacyEntries(out)
+	if !removedBlock && !removedLegacy {
+		return false, nil
+	}
+	if strings.TrimSpace(out) == "" {
+		out = ""
+	}
+	f.content = out
+	return true, nil
+}
+
+// Block returns the promptly block, or "" if the file has none.
+func (f *RCFile) Block() (string, error) {
+	start, end, found, err := findManagedBlock(f.content)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", f.Path, err)
+	}
+	if !found {
+		return "", nil
+	}
+	return f.content[start:end], nil
+}
+
+// ReplaceInBlock replaces every old with new inside the promptly block. It
+// reports whether anything was replaced.
+func (f *RCFile) ReplaceInBlock(old, new string) (bool, error) {
+	block, err := f.Block()
+	if err != nil || !strings.Contains(block, old) {
+		return false, err
+	}
+	start, end, _, _ := findManagedBlock(f.content)
+	f.content = f.content[:start] + strings.ReplaceAll(block, old, new) + f.content[end:]
+	return true, nil
+}
+
+// Save backs the original file up and writes the edited contents to Target
+// with the original file mode. It does nothing if there are no changes.
+func (f *RCFile) Save(tx *Transaction) error {
+	if !f.Changed() {
+		return nil
+	}
+
+	if f.exists && !tx.DryRun() {
+		if _, err := backupRCFile(f.Target); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", f.Path, err)
+		}
+	}
+	if err := tx.MkdirAll(filepath.Dir(f.Target), 0755); err != nil {
+		return err
+	}
+	if err := tx.WriteFile(f.Target, []byte(f.content), f.mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.Path, err)
+	}
+
+	f.original = []byte(f.content)
+	f.exists = true
+	return nil
+}
+
+// renderManagedBlock returns the marker-delimited block holding lines.
+func renderManagedBlock(lines []string, nl string) string {
+	var b strings.Builder
+	b.WriteString(rcBlockBegin + nl)
+	b.WriteString(rcBlockNote + nl)
+	for _, line := range lines {
+		b.WriteString(line + nl)
+	}
+	b.WriteString(rcBlockEnd + nl)
+	return b.String()
+}
+
+// findManagedBlock returns the byte range of the promptly block in content,
+// including the end marker's line ending.
+func findManagedBlock(content string) (start, end int, found bool, err error) {
+	start = indexLine(content, rcBlockBegin, 0)
+	if start < 0 {
+		return 0, 0, false, nil
+	}
+	endLine := indexLine(content, rcBlockEnd, start)
+	if endLine < 0 {
+		return 0, 0, false, fmt.Errorf("found %q without a matching %q", rcBlockBegin, rcBlockEnd)
+	}
+	end = endLine + len(rcBlockEnd)
+	for end < len(content) && (content[end] == ' ' || content[end] == '\t' || content[end] == '\r') {
+		end++
+	}
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return start, end, true, nil
+}
+
+// indexLine returns the offset of the first line at or after from that
+// consists of exactly line, ignoring trailing whitespace, or -1.
+func indexLine(content, line string, from int) int {
+	for i := from; i < len(content); {
+		next := strings.IndexByte(content[i:], '\n')
+		var cur string
+		if next < 0 {
+			cur = content[i:]
+		} else {
+			cur = content[i : i+next]
+		}
+		if strings.TrimRight(cur, " \t\r") == line {
+			return i
+		}
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return -1
+}
+
+// setManagedBlock replaces the promptly block in content with one holding
+// lines, or appends a new block after a blank separator line.
+func setManagedBlock(content string, lines []string, nl string) (string, error) {
+	block := renderManagedBlock(lines, nl)
+
+	start, end, found, err := findManagedBlock(content)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		return content[:start] + block + content[end:], nil
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += nl
+	}
+	if content != "" && !strings.HasSuffix(content, nl+nl) {
+		content += nl
+	}
+	return content + block, nil
+}
+
+// removeManagedBlock deletes the promptly block from content along with the
+// blank separator setManagedBlock put in front of it.
+func removeManagedBlock(content, nl string) (string, bool, error) {
+	start, end, found, err := findManagedBlock(content)
+	if err != nil || !found {
+		return content, false, err
+	}
+
+	before, after := content[:start], content[end:]
+	if after == "" && strings.HasSuffix(before, nl+nl) {
+		before = before[:len(before)-len(nl)]
+	}
+	return before + after, true, nil
+}
+
+// removeLegacyEntries deletes the comment and command pairs older versions of
+// promptly appended, together with the blank line in front of each pair.
+func removeLegacyEntries(content string) (string, bool) {
+	lines := strings.SplitAfter(content, "\n")
+	var kept []string
+	removed := false
+	for i := 0; i < len(lines); i++ {
+		if !isLegacyComment(lines[i]) {
+			kept = append(kept, lines[i])
+			continue
+		}
+		if n := len(kept); n > 0 && isBlankLine(kept[n-1]) {
+			kept = kept[:n-1]
+		}
+		if i+1 < len(lines) && !isBlankLine(lines[i+1]) {
+			i++
+		}
+		removed = true
+	}
+	if !removed {
+		return content, false
+	}
+	return strings.Join(kept, ""), true
+}
+
+func isLegacyComment(line string) bool {
+	line = strings.TrimSpace(line)
+	for _, c := range legacyRCComments {
+		if line == c {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlankLine(line string) bool {
+	return strings.TrimRight(line, "\r\n") == ""
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// editRC applies edit to the rc file at path and saves it.
+func editRC(t *testing.T, path string, edit func(rc *RCFile) error) {
+	t.Helper()
+	err := runTransaction(func(tx *Transaction) error {
+		rc, err := readRCFile(path)
+		if err != nil {
+			return err
+		}
+		if err := edit(rc); err != nil {
+			return err
+		}
+		return rc.Save(tx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setBlock(lines ...string) func(rc *RCFile) error {
+	return func(rc *RCFile) error { return rc.SetBlock(lines) }
+}
+
+func removeBlock(rc *RCFile) error {
+	_, err := rc.RemoveBlock()
+	return err
+}
+
+func writeRC(t *testing.T, content string, perm os.FileMode) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, ".zshrc")
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRCFileHugeLines(t *testing.T) {
+	huge := strings.Repeat("x", 256*1024)
+	original := "export A=" + huge + "\nalias ll='ls -l'\nexport B=" + huge + "\n"
+	path := writeRC(t, original, 0644)
+
+	editRC(t, path, setBlock("source ~/.promptly.zsh"))
+
+	got := readString(t, path)
+	if !strings.HasPrefix(got, original) {
+		t.Fatalf("user config was not preserved: got %d bytes, want prefix of %d bytes", len(got), len(original))
+	}
+	if !strings.Contains(got, "source ~/.promptly.zsh\n") {
+		t.Fatalf("block was not written:\n%s", got[len(original):])
+	}
+
+	editRC(t, path, removeBlock)
+	if got := readString(t, path); got != original {
+		t.Fatalf("remove did not restore the original file: got %d bytes, want %d", len(got), len(original))
+	}
+}
+
+func TestRCFileMissingTrailingNewline(t *testing.T) {
+	path := writeRC(t, "alias ll='ls -l'", 0644)
+
+	editRC(t, path, setBlock("source ~/.promptly.zsh"))
+
+	want := "alias ll='ls -l'\n\n" + rcBlockBegin + "\n" + rcBlockNote + "\nsource ~/.promptly.zsh\n" + rcBlockEnd + "\n"
+	if got := readString(t, path); got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	editRC(t, path, removeBlock)
+	if got := readString(t, path); got != "alias ll='ls -l'\n" {
+		t.Fatalf("got %q after remove", got)
+	}
+}
+
+func TestRCFileCRLF(t *testing.T) {
+	original := "alias ll='ls -l'\r\nexport EDITOR=vim\r\n"
+	path := writeRC(t, original, 0644)
+
+	editRC(t, path, setBlock("source ~/.promptly.zsh"))
+
+	got := readString(t, path)
+	if !strings.HasPrefix(got, original) {
+		t.Fatalf("user config was not preserved: %q", got)
+	}
+	if strings.Count(got, "\n") != strings.Count(got, "\r\n") {
+		t.Fatalf("block mixes line endings: %q", got)
+	}
+
+	// Replacing the block keeps CRLF and doesn't duplicate it.
+	editRC(t, path, setBlock("source ~/.promptly.fish"))
+	got = readString(t, path)
+	if strings.Count(got, rcBlockBegin) != 1 || strings.Contains(got, "promptly.zsh") {
+		t.Fatalf("block was not replaced in place: %q", got)
+	}
+
+	editRC(t, path, removeBlock)
+	if got := readString(t, path); got != original {
+		t.Fatalf("got %q after remove, want %q", got, original)
+	}
+}
+
+func TestRCFileSymlink(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dotfiles := filepath.Join(home, "dotfiles")
+	if err := os.Mkdir(dotfiles, 0755); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dotfiles, "zshrc")
+	if err := os.WriteFile(target, []byte("alias ll='ls -l'\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(home, ".zshrc")
+	if err := os.Symlink("dotfiles/zshrc", link); err != nil {
+		t.Fatal(err)
+	}
+
+	editRC(t, link, setBlock("source ~/.promptly.zsh"))
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("rc file symlink was replaced by a regular file")
+	}
+	if got := readString(t, target); !strings.Contains(got, "source ~/.promptly.zsh") {
+		t.Fatalf("symlink target was not updated: %q", got)
+	}
+	info, err = os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("file mode changed to %o, want 600", perm)
+	}
+}
+
+func TestRCFileReplacesLegacyEntries(t *testing.T) {
+	original := "alias ll='ls -l'\n\n# Promptly - Starship config\nexport STARSHIP_CONFIG=/old.toml\n\n# Promptly - Starship init\neval \"$(starship init zsh)\"\n"
+	path := writeRC(t, original, 0644)
+
+	editRC(t, path, setBlock("export STARSHIP_CONFIG=/new.toml", `eval "$(starship init zsh)"`))
+	editRC(t, path, setBlock("export STARSHIP_CONFIG=/new.toml", `eval "$(starship init zsh)"`))
+
+	got := readString(t, path)
+	if strings.Contains(got, "/old.toml") || strings.Contains(got, "# Promptly - ") {
+		t.Fatalf("legacy entries were not removed:\n%s", got)
+	}
+	if n := strings.Count(got, "STARSHIP_CONFIG"); n != 1 {
+		t.Fatalf("found %d STARSHIP_CONFIG lines, want 1:\n%s", n, got)
+	}
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Native prompt renderer
+// ─────────────────────────────────────────────────────────────
+
+// nativeShells are the shells promptly prompt draws prompts for.
+var nativeShells = []ShellTarget{ShellZsh, ShellBash, ShellFish}
+
+// RenderOptions choose what promptly prompt draws. They are passed from
+// promptly init to every promptly prompt it runs.
+type RenderOptions struct {
+	Theme   string
+	Palette string
+}
+
+// addRenderFlags registers the flags shared by promptly init and promptly
+// prompt, including --colors and --no-icons.
+func addRenderFlags(fs *flag.FlagSet) *RenderOptions {
+	opts := &RenderOptions{}
+	fs.StringVar(&opts.Theme, "theme", "default", "theme to draw")
+	fs.StringVar(&opts.Palette, "palette", "", "recolor the theme with this palette (default: the theme's own colors)")
+	addColorsFlag(fs)
+	addNoIconsFlag(fs)
+	return opts
+}
+
+// args returns the flags that give a promptly prompt the same options.
+func (o RenderOptions) args() []string {
+	args := []string{"--theme", o.Theme}
+	if o.Palette != "" {
+		args = append(args, "--palette", o.Palette)
+	}
+	if colorsOverride != DepthAuto {
+		args = append(args, "--colors", colorsOverride.String())
+	}
+	if noIcons {
+		args = append(args, "--no-icons")
+	}
+	return args
+}
+
+// parseNativeShell parses a shell promptly prompt can draw for.
+func parseNativeShell(s string) (ShellTarget, error) {
+	for _, shell := range nativeShells {
+		if s == string(shell) {
+			return shell, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported shell %q (expected zsh, bash or fish)", s)
+}
+
+// loadRenderSpec reads the spec of the built-in or custom theme opts names,
+// recolored with its palette. Only the spec is read, not every theme, since
+// this runs for every prompt.
+func loadRenderSpec(opts RenderOptions) (ThemeSpec, error) {
+	file := opts.Theme + specSuffix
+	data, err := fs.ReadFile(themeFiles, file)
+	if errors.Is(err, fs.ErrNotExist) {
+		paths, perr := resolvePaths()
+		if perr != nil {
+			return ThemeSpec{}, perr
+		}
+		data, err = os.ReadFile(filepath.Join(paths.Config, file))
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return ThemeSpec{}, fmt.Errorf("theme %q not found, or it has no theme spec for promptly to draw", opts.Theme)
+	}
+	if err != nil {
+		return ThemeSpec{}, err
+	}
+	spec, err := parseThemeSpec(data)
+	if err != nil {
+		return ThemeSpec{}, fmt.Errorf("%s: %w", file, err)
+	}
+
+	if opts.Palette != "" {
+		palettes, err := loadPalettes()
+		if err != nil {
+			return ThemeSpec{}, fmt.Errorf("failed to load palettes: %w", err)
+		}
+		palette, err := findPalette(palettes, opts.Palette)
+		if err != nil {
+			return ThemeSpec{}, err
+		}
+		spec = spec.withPalette(palette)
+	}
+	if noIcons {
+		spec = spec.withTextIcons()
+	}
+	return spec, nil
+}
+
+// GitInfo is what the git segments show about the repository the prompt is
+// drawn in.
+type GitInfo struct {
+	// Branch is the branch, the tag of a detached HEAD, or DETACHED.
+	Branch string
+	// GitHub is set when origin or upstream is on github.com.
+	GitHub                               bool
+	Ahead, Behind                        int
+	Staged, Unstaged, Untracked, Stashed int
+}
+
+// readGitInfo collects the git segments' values with a single git status,
+// a git config for the remotes and, only on a detached HEAD, a git describe
+// for its tag. The compiled themes run seven git commands for the same. It
+// returns nil when dir, or the working directory if empty, isn't in a git
+// repository.
+func readGitInfo(dir string) *GitInfo {
+	git := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		return cmd.Output()
+	}
+
+	// Older gits ignore status.showStash and leave out the stash count.
+	out, err := git("-c", "status.showStash=true", "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return nil
+	}
+
+	info := &GitInfo{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if value, ok := strings.CutPrefix(line, "# branch.head "); ok {
+			info.Branch = value
+		} else if value, ok := strings.CutPrefix(line, "# branch.ab "); ok {
+			fmt.Sscanf(value, "+%d -%d", &info.Ahead, &info.Behind)
+		} else if value, ok := strings.CutPrefix(line, "# stash "); ok {
+			info.Stashed, _ = strconv.Atoi(value)
+		} else if strings.HasPrefix(line, "? ") {
+			info.Untracked++
+		} else if len(line) > 4 && strings.ContainsRune("12u", rune(line[0])) {
+			// Counted like the compiled themes do: staged when the index
+			// has the change, unstaged only when it doesn't.
+			switch x, y := line[2], line[3]; {
+			case strings.IndexByte("AMDRCU", x) >= 0:
+				info.Staged++
+			case y == 'M' || y == 'D':
+				info.Unstaged++
+			}
+		}
+	}
+	if info.Branch == "(detached)" {
+		info.Branch = "DETACHED"
+		if tag, err := git("describe", "--tags", "--exact-match"); err == nil {
+			info.Branch = strings.TrimSpace(string(tag))
+		}
+	}
+
+	remotes, _ := git("config", "--get-regexp", `^remote\.(origin|upstream)\.url$`)
+	info.GitHub = strings.Contains(string(remotes), "github.com")
+	return info
+}
+
+// values returns the text of each git segment, as the compiled themes' git
+// info functions set it. Segments with an empty value aren't drawn.
+func (g *GitInfo) values(s ThemeSpec) map[string]string {
+	values := map[string]string{
+		segBranch:  g.Branch,
+		segGitHost: s.Icons.Git,
+	}
+	if g.GitHub {
+		values[segGitHost] = s.Icons.GitHub
+	}
+	switch {
+	case g.Ahead > 0 && g.Behind > 0:
+		values[segSync] = fmt.Sprintf("%s%s%d/%d", s.Icons.Diverged, s.SyncSeparator, g.Ahead, g.Behind)
+	case g.Ahead > 0:
+		values[segSync] = fmt.Sprintf("%s%s%d", s.Icons.Ahead, s.SyncSeparator, g.Ahead)
+	case g.Behind > 0:
+		values[segSync] = fmt.Sprintf("%s%s%d", s.Icons.Behind, s.SyncSeparator, g.Behind)
+	}
+	counts := []struct {
+		seg, icon string
+		n         int
+	}{
+		{segStaged, s.Icons.Staged, g.Staged},
+		{segUnstaged, s.Icons.Unstaged, g.Unstaged},
+		{segUntracked, s.Icons.Untracked, g.Untracked},
+		{segStashed, s.Icons.Stashed, g.Stashed},
+	}
+	for _, c := range counts {
+		if c.n > 0 {
+			values[c.seg] = c.icon + strconv.Itoa(c.n)
+		}
+	}
+	return values
+}
+
+// homeRelative returns the working directory with the home directory shown
+// as ~, the way the fish themes draw it.
+func homeRelative() string {
+	dir := os.Getenv("PWD")
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	home := os.Getenv("HOME")
+	if home != "" && (dir == home || strings.HasPrefix(dir, home+"/")) {
+		return "~" + dir[len(home):]
+	}
+	return dir
+}
+
+// renderPrompt draws spec as a prompt string for shell, drawn the same as
+// the theme compiled for it. git is nil outside a git repository, and status
+// is the exit status of the last command. Every text, from the spec or from
+// git, is escaped, so zsh and bash show it as is.
+func renderPrompt(shell ShellTarget, s ThemeSpec, git *GitInfo, status int) string {
+	var color func(Color) string
+	var reset, dir string
+	switch shell {
+	case ShellZsh:
+		color = func(c Color) string { return "%F{" + c.Zsh() + "}" }
+		reset, dir = "%f", "%~"
+	case ShellBash:
+		color = func(c Color) string { return `\[\e[` + c.SGR() + `m\]` }
+		reset, dir = `\[\e[0m\]`, `\w`
+	default:
+		color = func(c Color) string { return "\033[" + c.SGR() + "m" }
+		reset, dir = "\033[0m", escapePromptValue(shell, homeRelative())
+	}
+	text := func(s string) string { return escapePromptValue(shell, s) }
+
+	var values map[string]string
+	if git != nil {
+		values = git.values(s)
+	}
+
+	var b strings.Builder
+	if s.BlankLine {
+		b.WriteString("\n")
+	}
+	for _, seg := range s.Segments {
+		switch seg.Type {
+		case segNewline:
+			b.WriteString("\n")
+		case segDir:
+			b.WriteString(color(s.mustColor(seg.Color)) + text(seg.Prefix) + dir + reset)
+		case segText:
+			if !seg.Git || git != nil {
+				b.WriteString(color(s.mustColor(seg.Color)) + text(seg.Prefix+seg.Text) + reset)
+			}
+		case segChar:
+			c := s.mustColor(seg.Color)
+			if seg.ErrorColor != "" && status != 0 {
+				c = s.mustColor(seg.ErrorColor)
+			}
+			b.WriteString(color(c) + text(seg.Prefix+s.PromptChar) + reset + " ")
+		case segGitHost, segBranch:
+			if git != nil {
+				b.WriteString(color(s.mustColor(seg.Color)) + text(seg.Prefix+values[seg.Type]) + reset)
+			}
+		default:
+			if value := values[seg.Type]; value != "" {
+				b.WriteString(color(s.mustColor(seg.Color)) + text(seg.Prefix+value) + reset)
+			}
+		}
+	}
+	return b.String()
+}
+
+// ─────────────────────────────────────────────────────────────
+// promptly init
+// ─────────────────────────────────────────────────────────────
+
+// initScripts hook promptly prompt into each shell. %[1]s is the command
+// that draws the prompt; zsh and bash add the last exit status to it.
+var initScripts = map[ShellTarget]string{
+	ShellZsh: `# promptly init zsh: draws the prompt with promptly prompt before every
+# command line. prompt_subst is on, so promptly escapes what it prints.
+setopt prompt_subst
+
+__promptly_prompt() {
+  PROMPT=$(%[1]s --status $?)
+}
+
+autoload -Uz add-zsh-hook
+add-zsh-hook precmd __promptly_prompt
+`,
+	ShellBash: `# promptly init bash: draws the prompt with promptly prompt before every
+# command line, escaped so PS1 only shows it.
+__promptly_prompt() {
+  local last_status=$?
+  PS1=$(%[1]s --status "$last_status")
+  return $last_status
+}
+
+# Run before every prompt without clobbering other PROMPT_COMMAND hooks
+case ";${PROMPT_COMMAND:-};" in
+  *";__promptly_prompt;"*) ;;
+  *) PROMPT_COMMAND="__promptly_prompt${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+`,
+	ShellFish: `# promptly init fish: draws the prompt with promptly prompt.
+function fish_prompt
+    %[1]s --status $status
+end
+`,
+}
+
+// plainWord matches arguments that need no quoting in zsh, bash or fish.
+var plainWord = regexp.MustCompile(`^[A-Za-z0-9_./=-]+$`)
+
+// quoteWord quotes s for shell, unless it is a plain word.
+func quoteWord(shell ShellTarget, s string) string {
+	switch {
+	case plainWord.MatchString(s):
+		return s
+	case shell == ShellFish:
+		return fishQuote(s)
+	}
+	return shQuote(s)
+}
+
+// promptlyCommand returns how the shell hooks run promptly: by name when it
+// is the promptly on $PATH, so they keep working across upgrades, or else by
+// its path.
+func promptlyCommand() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "promptly"
+	}
+	onPath, err := exec.LookPath("promptly")
+	if err != nil {
+		return exe
+	}
+	a, errA := os.Stat(exe)
+	b, errB := os.Stat(onPath)
+	if errA == nil && errB == nil && os.SameFile(a, b) {
+		return "promptly"
+	}
+	return exe
+}
+
+// commandLine quotes a promptly command line for shell.
+func commandLine(shell ShellTarget, args ...string) string {
+	words := []string{quoteWord(shell, promptlyCommand())}
+	for _, arg := range args {
+		words = append(words, quoteWord(shell, arg))
+	}
+	return strings.Join(words, " ")
+}
+
+// initScript returns the code that makes shell draw its prompt with promptly
+// prompt and opts.
+func initScript(shell ShellTarget, opts RenderOptions) string {
+	args := append([]string{"prompt", "--shell", string(shell)}, opts.args()...)
+	return fmt.Sprintf(initScripts[shell], commandLine(shell, args...))
+}
+
+// nativeStubHeader starts the file installed for a shell by install
+// --native.
+const nativeStubHeader = "# Promptly prompt, drawn by promptly itself\n"
+
+// nativeStub returns the file installed for shell that loads promptly init
+// in place of a compiled theme.
+func nativeStub(shell ShellTarget, opts RenderOptions) string {
+	init := commandLine(shell, append([]string{"init", string(shell)}, opts.args()...)...)
+	if shell == ShellFish {
+		return nativeStubHeader + init + " | source\n"
+	}
+	return nativeStubHeader + `eval "$(` + init + `)"` + "\n"
+}
+
+// nativeStubTheme matches the theme a native stub draws. Theme names are
+// plain words, so they are never quoted.
+var nativeStubTheme = regexp.MustCompile(`--theme ([A-Za-z0-9_-]+)`)
+
+// isNativeStubFor reports whether content is a native stub drawing theme.
+func isNativeStubFor(content, theme string) bool {
+	if !strings.HasPrefix(content, nativeStubHeader) {
+		return false
+	}
+	m := nativeStubTheme.FindStringSubmatch(content)
+	return m != nil && m[1] == theme
+}
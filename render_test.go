@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// runGit runs git in dir with a fixed identity.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=promptly", "GIT_AUTHOR_EMAIL=promptly@example.com",
+		"GIT_COMMITTER_NAME=promptly", "GIT_COMMITTER_EMAIL=promptly@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+// busyRepo returns a clone of a GitHub repository with something in every
+// git segment: one commit ahead, one staged, unstaged and untracked file,
+// and a stash.
+func busyRepo(t *testing.T) string {
+	t.Helper()
+	requireShell(t, "git")
+	origin, repo := t.TempDir(), t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runGit(t, origin, "init", "-q", "-b", "main")
+	runGit(t, origin, "commit", "-q", "--allow-empty", "-m", "first")
+	runGit(t, repo, "clone", "-q", origin, ".")
+	write("tracked.txt", "one\n")
+	runGit(t, repo, "add", "tracked.txt")
+	runGit(t, repo, "commit", "-q", "-m", "second")
+	write("tracked.txt", "two\n")
+	runGit(t, repo, "stash", "-q")
+	write("tracked.txt", "three\n")
+	write("staged.txt", "staged\n")
+	runGit(t, repo, "add", "staged.txt")
+	write("untracked.txt", "untracked\n")
+	runGit(t, repo, "remote", "set-url", "origin", "https://github.com/OwlfaceGames/promptly.git")
+	return repo
+}
+
+// builtinSpecs returns the specs of the built-in themes by name.
+func builtinSpecs(t *testing.T) map[string]ThemeSpec {
+	t.Helper()
+	files, err := filepath.Glob("*" + specSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	specs := make(map[string]ThemeSpec)
+	for _, file := range files {
+		spec, err := parseThemeSpec([]byte(readString(t, file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		specs[strings.TrimSuffix(file, specSuffix)] = spec
+	}
+	return specs
+}
+
+func TestReadGitInfo(t *testing.T) {
+	want := GitInfo{Branch: "main", GitHub: true, Ahead: 1, Staged: 1, Unstaged: 1, Untracked: 1, Stashed: 1}
+	if got := readGitInfo(busyRepo(t)); got == nil || *got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := readGitInfo(t.TempDir()); got != nil {
+		t.Errorf("outside a repository got %+v", got)
+	}
+}
+
+// TestRenderPromptMatchesCompiled checks that promptly prompt draws every
+// built-in theme exactly like its compiled zsh and bash variants.
+func TestRenderPromptMatchesCompiled(t *testing.T) {
+	repo := busyRepo(t)
+	git := readGitInfo(repo)
+	for name, spec := range builtinSpecs(t) {
+		contents := compileTheme(name, spec)
+		for _, shell := range []ShellTarget{ShellBash, ShellZsh} {
+			t.Run(name+"/"+string(shell), func(t *testing.T) {
+				requireShell(t, string(shell))
+				file := filepath.Join(t.TempDir(), name+themeSuffixes[shell])
+				if err := os.WriteFile(file, []byte(contents[shell]), 0644); err != nil {
+					t.Fatal(err)
+				}
+				statuses := []int{0}
+				if shell == ShellBash {
+					statuses = append(statuses, 1)
+				}
+				for _, status := range statuses {
+					compiled := expandPrompt(t, string(shell), repo,
+						". "+shQuote(file)+"; (exit "+strconv.Itoa(status)+"); __promptly_build_prompt", "")
+					native := expandPrompt(t, string(shell), repo,
+						map[ShellTarget]string{ShellBash: "PS1=$PROMPT_VALUE", ShellZsh: "PROMPT=$PROMPT_VALUE"}[shell],
+						renderPrompt(shell, spec, git, status))
+					if native != compiled {
+						t.Errorf("status %d:\nnative   %q\ncompiled %q", status, native, compiled)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRenderPromptMaliciousBranch(t *testing.T) {
+	requireShell(t, "git")
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "symbolic-ref", "HEAD", "refs/heads/"+maliciousBranch)
+	git := readGitInfo(repo)
+	if git == nil || git.Branch != maliciousBranch {
+		t.Fatalf("got %+v", git)
+	}
+
+	spec := builtinSpecs(t)["default"]
+	setups := map[ShellTarget]string{ShellBash: "PS1=$PROMPT_VALUE", ShellZsh: "PROMPT=$PROMPT_VALUE"}
+	for shell, setup := range setups {
+		t.Run(string(shell), func(t *testing.T) {
+			requireShell(t, string(shell))
+			got := expandPrompt(t, string(shell), repo, setup, renderPrompt(shell, spec, git, 0))
+			if !strings.Contains(got, maliciousBranch) {
+				t.Errorf("the prompt doesn't show the branch name as is:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestNativeStub(t *testing.T) {
+	for _, shell := range nativeShells {
+		stub := nativeStub(shell, RenderOptions{Theme: "mine", Palette: "melange"})
+		if !isNativeStubFor(stub, "mine") || isNativeStubFor(stub, "min") || isNativeStubFor(stub, "mine-2") {
+			t.Errorf("%s: the stub isn't told apart by theme:\n%s", shell, stub)
+		}
+	}
+}
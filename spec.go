@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Theme specs
+// ─────────────────────────────────────────────────────────────
+
+// specSuffix is the file suffix of a theme spec, the single source every
+// shell variant of a theme is compiled from.
+const specSuffix = ".promptly.theme.toml"
+
+// ThemeSpec describes what a prompt looks like independent of any shell:
+// an ordered list of segments, the colors they are drawn in and the icons
+// the git segments use.
+type ThemeSpec struct {
+	// PromptChar is the text of the char segment.
+	PromptChar string `toml:"prompt_char"`
+	// BlankLine prints an empty line before every prompt.
+	BlankLine bool `toml:"blank_line"`
+	// SyncSeparator goes between the ahead/behind icon and its count.
+	SyncSeparator string `toml:"sync_separator"`
+	// Colors maps role names to colors. Segments name a role or give a
+	// color directly.
+	Colors   map[string]string `toml:"colors"`
+	Icons    SpecIcons         `toml:"icons"`
+	Segments []SpecSegment     `toml:"segments"`
+	Starship SpecStarship      `toml:"starship,omitempty"`
+}
+
+// SpecIcons are the symbols the git segments are built from.
+type SpecIcons struct {
+	Git       string `toml:"git"`
+	GitHub    string `toml:"github"`
+	Ahead     string `toml:"ahead"`
+	Behind    string `toml:"behind"`
+	Diverged  string `toml:"diverged"`
+	Staged    string `toml:"staged"`
+	Unstaged  string `toml:"unstaged"`
+	Untracked string `toml:"untracked"`
+	Stashed   string `toml:"stashed"`
+}
+
+// SpecSegment is one part of the prompt. Prefix is drawn in the segment's
+// color, and only when the segment itself is shown.
+type SpecSegment struct {
+	Type   string `toml:"type"`
+	Text   string `toml:"text,omitempty"`
+	Prefix string `toml:"prefix,omitempty"`
+	Color  string `toml:"color,omitempty"`
+	// ErrorColor replaces Color on the char segment after a failed command.
+	ErrorColor string `toml:"error_color,omitempty"`
+	// Git limits a text segment to git repositories.
+	Git bool `toml:"git,omitempty"`
+}
+
+// SpecStarship holds starship settings with no equivalent in the other
+// shells.
+type SpecStarship struct {
+	// All adds starship's $all, every module not placed explicitly, before
+	// the first line break.
+	All bool `toml:"all,omitempty"`
+	// Extra is raw starship TOML appended to the generated config, e.g.
+	// module symbols. It must not redefine the tables promptly generates.
+	Extra string `toml:"extra,omitempty"`
+}
+
+// Segment types.
+const (
+	segDir       = "dir"
+	segText      = "text"
+	segGitHost   = "git_host"
+	segBranch    = "git_branch"
+	segSync      = "git_sync"
+	segStaged    = "git_staged"
+	segUnstaged  = "git_unstaged"
+	segUntracked = "git_untracked"
+	segStashed   = "git_stashed"
+	segNewline   = "newline"
+	segChar      = "char"
+)
+
+// gitStatusSegments are the segments starship draws with its git_status
+// module, in no particular order.
+var gitStatusSegments = []string{segSync, segStaged, segUnstaged, segUntracked, segStashed}
+
+// isGitSegment reports whether seg is only shown inside a git repository.
+func isGitSegment(seg SpecSegment) bool {
+	return strings.HasPrefix(seg.Type, "git_") || seg.Type == segText && seg.Git
+}
+
+// parseThemeSpec decodes and validates a theme spec.
+func parseThemeSpec(data []byte) (ThemeSpec, error) {
+	var spec ThemeSpec
+	md, err := toml.Decode(string(data), &spec)
+	if err != nil {
+		return ThemeSpec{}, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return ThemeSpec{}, fmt.Errorf("unknown key %q", undecoded[0].String())
+	}
+	if err := spec.validate(); err != nil {
+		return ThemeSpec{}, err
+	}
+	return spec, nil
+}
+
+// encodeThemeSpec renders spec as a theme spec file.
+func encodeThemeSpec(spec ThemeSpec) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(spec); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s ThemeSpec) validate() error {
+	if len(s.Segments) == 0 {
+		return fmt.Errorf("no segments")
+	}
+	for role, value := range s.Colors {
+		if _, err := parseColor(value); err != nil {
+			return fmt.Errorf("colors.%s: %w", role, err)
+		}
+	}
+
+	statusRun := -1
+	for i, seg := range s.Segments {
+		switch seg.Type {
+		case segDir, segText, segGitHost, segBranch, segSync, segStaged, segUnstaged, segUntracked, segStashed, segNewline, segChar:
+		default:
+			return fmt.Errorf("segment %d: unknown type %q", i+1, seg.Type)
+		}
+		if seg.Type != segNewline {
+			if _, err := s.color(seg.Color); err != nil {
+				return fmt.Errorf("segment %d (%s): %w", i+1, seg.Type, err)
+			}
+		}
+		if seg.ErrorColor != "" {
+			if seg.Type != segChar {
+				return fmt.Errorf("segment %d (%s): error_color only applies to char", i+1, seg.Type)
+			}
+			if _, err := s.color(seg.ErrorColor); err != nil {
+				return fmt.Errorf("segment %d (%s): %w", i+1, seg.Type, err)
+			}
+		}
+		if seg.Git && seg.Type != segText {
+			return fmt.Errorf("segment %d (%s): git only applies to text", i+1, seg.Type)
+		}
+
+		// starship draws all git status counts with one module, so they
+		// have to be next to each other.
+		if isStatusSegment(seg) {
+			if statusRun >= 0 && statusRun != i-1 {
+				return fmt.Errorf("segment %d (%s): git status segments must be next to each other", i+1, seg.Type)
+			}
+			statusRun = i
+		}
+	}
+
+	// starship can only show text in repositories as part of the git_host
+	// or git_branch module next to it.
+	for i, seg := range s.Segments {
+		if seg.Type == segText && seg.Git && s.gitTextAnchor(i) < 0 {
+			return fmt.Errorf("segment %d (text): git text must be next to git_host or git_branch", i+1)
+		}
+	}
+	return nil
+}
+
+func isStatusSegment(seg SpecSegment) bool {
+	for _, t := range gitStatusSegments {
+		if seg.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// gitTextAnchor returns the git_host or git_branch segment that the git text
+// segment at i belongs to: the next one, past other git texts, or else the
+// previous one. It returns -1 if there is none.
+func (s ThemeSpec) gitTextAnchor(i int) int {
+	isText := func(seg SpecSegment) bool { return seg.Type == segText && seg.Git }
+	isAnchor := func(seg SpecSegment) bool { return seg.Type == segGitHost || seg.Type == segBranch }
+
+	for j := i + 1; j < len(s.Segments); j++ {
+		if isAnchor(s.Segments[j]) {
+			return j
+		}
+		if !isText(s.Segments[j]) {
+			break
+		}
+	}
+	for j := i - 1; j >= 0; j-- {
+		if isAnchor(s.Segments[j]) {
+			return j
+		}
+		if !isText(s.Segments[j]) {
+			break
+		}
+	}
+	return -1
+}
+
+// color resolves a segment color: a role from Colors or a color value.
+func (s ThemeSpec) color(name string) (Color, error) {
+	if name == "" {
+		return Color{}, fmt.Errorf("missing color")
+	}
+	if value, ok := s.Colors[name]; ok {
+		return parseColor(value)
+	}
+	return parseColor(name)
+}
+
+// mustColor resolves a color of a validated spec, downsampled to the color
+// depth themes are compiled for.
+func (s ThemeSpec) mustColor(name string) Color {
+	return s.exactColor(name).downsample(themeColorDepth())
+}
+
+// exactColor resolves a color of a validated spec as it is written.
+func (s ThemeSpec) exactColor(name string) Color {
+	c, err := s.color(name)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// ─────────────────────────────────────────────────────────────
+// Colors
+// ─────────────────────────────────────────────────────────────
+
+// Color is a terminal foreground color: one of the eight basic colors, an
+// index into the 256-color palette, or a 24-bit RGB value.
+type Color struct {
+	Name    string // basic color name, when set
+	Index   int    // 256-color index, when Name is empty and RGB is false
+	RGB     bool
+	R, G, B uint8
+}
+
+var basicColors = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// parseColor parses a basic color name, a 256-color index or #rrggbb.
+func parseColor(s string) (Color, error) {
+	for _, name := range basicColors {
+		if s == name {
+			return Color{Name: s}, nil
+		}
+	}
+	if strings.HasPrefix(s, "#") {
+		v, err := strconv.ParseUint(s[1:], 16, 32)
+		if err != nil || len(s) != 7 {
+			return Color{}, fmt.Errorf("invalid color %q (expected #rrggbb)", s)
+		}
+		return Color{RGB: true, R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n > 255 {
+			return Color{}, fmt.Errorf("invalid color %q (indexes go from 0 to 255)", s)
+		}
+		return Color{Index: n}, nil
+	}
+	return Color{}, fmt.Errorf("unknown color %q (expected a role, a color name, 0-255 or #rrggbb)", s)
+}
+
+// SGR returns the parameters of the escape sequence that selects c. The
+// first 16 indexes use the basic sequences, which every terminal knows.
+func (c Color) SGR() string {
+	switch {
+	case c.Name != "":
+		return strconv.Itoa(30 + basicIndex(c.Name))
+	case !c.RGB && c.Index < 8:
+		return strconv.Itoa(30 + c.Index)
+	case !c.RGB && c.Index < 16:
+		return strconv.Itoa(90 + c.Index - 8)
+	case c.RGB:
+		return fmt.Sprintf("38;2;%d;%d;%d", c.R, c.G, c.B)
+	default:
+		return fmt.Sprintf("38;5;%d", c.Index)
+	}
+}
+
+// Hex returns c as #rrggbb, mapping basic and indexed colors through the
+// xterm palette.
+func (c Color) Hex() string {
+	r, g, b := c.rgb()
+	return fmt.Sprintf("#%02X%02X%02X", r, g, b)
+}
+
+// Zsh returns c as a %F{...} argument.
+func (c Color) Zsh() string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case c.RGB:
+		return c.Hex()
+	default:
+		return strconv.Itoa(c.Index)
+	}
+}
+
+// Fish returns c as a set_color argument. fish has no 256-color indexes, so
+// those past the basic 16 are converted to RGB.
+func (c Color) Fish() string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case !c.RGB && c.Index < 8:
+		return basicColors[c.Index]
+	case !c.RGB && c.Index < 16:
+		return "br" + basicColors[c.Index-8]
+	}
+	return strings.TrimPrefix(c.Hex(), "#")
+}
+
+// Starship returns c as a starship style, which calls magenta purple.
+func (c Color) Starship() string {
+	name := c.Name
+	if !c.RGB && name == "" && c.Index < 16 {
+		name = basicColors[c.Index%8]
+		if c.Index >= 8 {
+			name = "bright-" + name
+		}
+	}
+	if name != "" {
+		return strings.Replace(name, "magenta", "purple", 1)
+	}
+	return c.Zsh()
+}
+
+func basicIndex(name string) int {
+	for i, n := range basicColors {
+		if n == name {
+			return i
+		}
+	}
+	return 7
+}
+
+// xtermBasic are the RGB values xterm uses for the 16 basic colors.
+var xtermBasic = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+func (c Color) rgb() (r, g, b uint8) {
+	switch {
+	case c.RGB:
+		return c.R, c.G, c.B
+	case c.Name != "":
+		v := xtermBasic[basicIndex(c.Name)]
+		return v[0], v[1], v[2]
+	case c.Index < 16:
+		v := xtermBasic[c.Index]
+		return v[0], v[1], v[2]
+	case c.Index < 232:
+		// 6x6x6 color cube
+		levels := [6]uint8{0, 95, 135, 175, 215, 255}
+		i := c.Index - 16
+		return levels[i/36], levels[i/6%6], levels[i%6]
+	default:
+		// grayscale ramp
+		v := uint8(8 + (c.Index-232)*10)
+		return v, v, v
+	}
+}
@@ -0,0 +1,92 @@
+# default.promptly.bash
+# Generated by promptly from default.promptly.theme.toml. Edit the spec, not this file.
+
+# PS1 is rebuilt from PROMPT_COMMAND before every prompt. Color escapes are
+# wrapped in \[ \] so readline knows they take up no space, and PS1 only
+# refers to git values and texts by name, so nothing in a branch name is
+# ever expanded or run.
+
+PROMPTLY_GIT_ICON='git'
+PROMPTLY_GITHUB_ICON='github'
+PROMPTLY_AHEAD_ICON=''
+PROMPTLY_BEHIND_ICON=''
+PROMPTLY_DIVERGED_ICON=''
+PROMPTLY_STAGED_ICON='+'
+PROMPTLY_UNSTAGED_ICON='!'
+PROMPTLY_UNTRACKED_ICON='?'
+PROMPTLY_STASHED_ICON='$'
+PROMPTLY_SYNC_SEPARATOR=' '
+
+__promptly_git_info() {
+  __promptly_git_host= __promptly_git_branch= __promptly_git_sync=
+  __promptly_git_staged= __promptly_git_unstaged= __promptly_git_untracked= __promptly_git_stashed=
+  git rev-parse --git-dir > /dev/null 2>&1 || return 0
+
+  __promptly_git_branch=$(git symbolic-ref --short HEAD 2>/dev/null || git describe --tags --exact-match 2>/dev/null || echo "DETACHED")
+
+  # Single git status call with branch info
+  local line staged=0 unstaged=0 untracked=0
+  while IFS= read -r line; do
+    case "${line:0:2}" in
+      "##") ;;  # Branch info line
+      [AMDRCU]?) ((staged++)) ;;
+      ?[MD]) ((unstaged++)) ;;
+      "??") ((untracked++)) ;;
+    esac
+  done <<< "$(git status --porcelain -b 2>/dev/null)"
+
+  local stashed
+  stashed=$(git stash list 2>/dev/null | wc -l | tr -d ' ')
+
+  # Detect GitHub by remote URL
+  local remotes
+  remotes="$(git config --get remote.origin.url 2>/dev/null) $(git config --get remote.upstream.url 2>/dev/null)"
+  __promptly_git_host=$PROMPTLY_GIT_ICON
+  [[ $remotes == *github.com* ]] && __promptly_git_host=$PROMPTLY_GITHUB_ICON
+
+  # Ahead / behind
+  local counts ahead=0 behind=0
+  if counts=$(git rev-list --left-right --count 'HEAD...@{u}' 2>/dev/null); then
+    read -r ahead behind <<< "$counts"
+  fi
+  if (( ahead > 0 && behind > 0 )); then
+    __promptly_git_sync="${PROMPTLY_DIVERGED_ICON}${PROMPTLY_SYNC_SEPARATOR}${ahead}/${behind}"
+  elif (( ahead > 0 )); then
+    __promptly_git_sync="${PROMPTLY_AHEAD_ICON}${PROMPTLY_SYNC_SEPARATOR}${ahead}"
+  elif (( behind > 0 )); then
+    __promptly_git_sync="${PROMPTLY_BEHIND_ICON}${PROMPTLY_SYNC_SEPARATOR}${behind}"
+  fi
+
+  (( staged > 0 )) && __promptly_git_staged="${PROMPTLY_STAGED_ICON}${staged}"
+  (( unstaged > 0 )) && __promptly_git_unstaged="${PROMPTLY_UNSTAGED_ICON}${unstaged}"
+  (( untracked > 0 )) && __promptly_git_untracked="${PROMPTLY_UNTRACKED_ICON}${untracked}"
+  (( stashed > 0 )) && __promptly_git_stashed="${PROMPTLY_STASHED_ICON}${stashed}"
+  return 0
+}
+
+__promptly_build_prompt() {
+  local last_status=$?
+  __promptly_git_info
+  local p=''
+  p+='\n'
+  p+='\[\e[36m\]\w\[\e[0m\]'
+  [[ -n $__promptly_git_branch ]] && p+='\[\e[34m\] ${__promptly_git_host}\[\e[0m\]'
+  [[ -n $__promptly_git_branch ]] && p+='\[\e[34m\](\[\e[0m\]'
+  [[ -n $__promptly_git_branch ]] && p+='\[\e[95m\]${__promptly_git_branch}\[\e[0m\]'
+  [[ -n $__promptly_git_branch ]] && p+='\[\e[34m\])\[\e[0m\]'
+  [[ -n $__promptly_git_sync ]] && p+='\[\e[36m\] ${__promptly_git_sync}\[\e[0m\]'
+  [[ -n $__promptly_git_staged ]] && p+='\[\e[32m\] ${__promptly_git_staged}\[\e[0m\]'
+  [[ -n $__promptly_git_unstaged ]] && p+='\[\e[33m\] ${__promptly_git_unstaged}\[\e[0m\]'
+  [[ -n $__promptly_git_untracked ]] && p+='\[\e[31m\] ${__promptly_git_untracked}\[\e[0m\]'
+  [[ -n $__promptly_git_stashed ]] && p+='\[\e[37m\] ${__promptly_git_stashed}\[\e[0m\]'
+  p+='\n'
+  p+='\[\e[34m\]❯\[\e[0m\] '
+  PS1=$p
+  return $last_status
+}
+
+# Run before every prompt without clobbering other PROMPT_COMMAND hooks
+case ";${PROMPT_COMMAND:-};" in
+  *";__promptly_build_prompt;"*) ;;
+  *) PROMPT_COMMAND="__promptly_build_prompt${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
@@ -0,0 +1,91 @@
+# default.promptly.fish
+# Generated by promptly from default.promptly.theme.toml. Edit the spec, not this file.
+
+set -g __promptly_git_icon 'git'
+set -g __promptly_github_icon 'github'
+set -g __promptly_ahead_icon ''
+set -g __promptly_behind_icon ''
+set -g __promptly_diverged_icon ''
+set -g __promptly_staged_icon '+'
+set -g __promptly_unstaged_icon '!'
+set -g __promptly_untracked_icon '?'
+set -g __promptly_stashed_icon '$'
+set -g __promptly_sync_separator ' '
+
+function __promptly_git_info
+    set -g __promptly_git_host ''
+    set -g __promptly_git_branch ''
+    set -g __promptly_git_sync ''
+    set -g __promptly_git_staged ''
+    set -g __promptly_git_unstaged ''
+    set -g __promptly_git_untracked ''
+    set -g __promptly_git_stashed ''
+    git rev-parse --git-dir >/dev/null 2>&1; or return 0
+
+    set -g __promptly_git_branch (git symbolic-ref --short HEAD 2>/dev/null; \
+        or git describe --tags --exact-match 2>/dev/null; \
+        or echo DETACHED)
+
+    # Single git status call with branch info
+    set -l staged 0
+    set -l unstaged 0
+    set -l untracked 0
+    for line in (git status --porcelain -b 2>/dev/null)
+        set -l xy (string sub -l 2 -- $line)
+        if test "$xy" = '##'
+            continue
+        else if string match -qr '^[AMDRCU]' -- $xy
+            set staged (math $staged + 1)
+        else if string match -qr '^.[MD]' -- $xy
+            set unstaged (math $unstaged + 1)
+        else if test "$xy" = '??'
+            set untracked (math $untracked + 1)
+        end
+    end
+    set -l stashed (git stash list 2>/dev/null | count)
+
+    # Detect GitHub by remote URL
+    set -g __promptly_git_host $__promptly_git_icon
+    if string match -q '*github.com*' -- (git config --get remote.origin.url 2>/dev/null) (git config --get remote.upstream.url 2>/dev/null)
+        set -g __promptly_git_host $__promptly_github_icon
+    end
+
+    # Ahead / behind
+    set -l counts (git rev-list --left-right --count 'HEAD...@{u}' 2>/dev/null | string split \t)
+    if test (count $counts) -eq 2
+        set -l ahead $counts[1]
+        set -l behind $counts[2]
+        if test $ahead -gt 0 -a $behind -gt 0
+            set -g __promptly_git_sync "$__promptly_diverged_icon$__promptly_sync_separator$ahead/$behind"
+        else if test $ahead -gt 0
+            set -g __promptly_git_sync "$__promptly_ahead_icon$__promptly_sync_separator$ahead"
+        else if test $behind -gt 0
+            set -g __promptly_git_sync "$__promptly_behind_icon$__promptly_sync_separator$behind"
+        end
+    end
+
+    test $staged -gt 0; and set -g __promptly_git_staged "$__promptly_staged_icon$staged"
+    test $unstaged -gt 0; and set -g __promptly_git_unstaged "$__promptly_unstaged_icon$unstaged"
+    test $untracked -gt 0; and set -g __promptly_git_untracked "$__promptly_untracked_icon$untracked"
+    test $stashed -gt 0; and set -g __promptly_git_stashed "$__promptly_stashed_icon$stashed"
+    return 0
+end
+
+function fish_prompt
+    set -l last_status $status
+    __promptly_git_info
+    echo
+    set_color cyan; printf '%s' (string replace -r -- '^'(string escape --style=regex -- $HOME) '~' $PWD)
+    test -n "$__promptly_git_branch"; and begin; set_color blue; printf '%s' ' ' $__promptly_git_host; end
+    test -n "$__promptly_git_branch"; and begin; set_color blue; printf '%s' '('; end
+    test -n "$__promptly_git_branch"; and begin; set_color brmagenta; printf '%s' $__promptly_git_branch; end
+    test -n "$__promptly_git_branch"; and begin; set_color blue; printf '%s' ')'; end
+    test -n "$__promptly_git_sync"; and begin; set_color cyan; printf '%s' ' ' $__promptly_git_sync; end
+    test -n "$__promptly_git_staged"; and begin; set_color green; printf '%s' ' ' $__promptly_git_staged; end
+    test -n "$__promptly_git_unstaged"; and begin; set_color yellow; printf '%s' ' ' $__promptly_git_unstaged; end
+    test -n "$__promptly_git_untracked"; and begin; set_color red; printf '%s' ' ' $__promptly_git_untracked; end
+    test -n "$__promptly_git_stashed"; and begin; set_color white; printf '%s' ' ' $__promptly_git_stashed; end
+    echo
+    set_color blue; printf '%s ' '❯'
+    set_color normal
+end
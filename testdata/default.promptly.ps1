@@ -0,0 +1,93 @@
+# default.promptly.ps1
+# Generated by promptly from default.promptly.theme.toml. Edit the spec, not this file.
+
+$PromptlyIcons = @{
+    Git           = 'git'
+    GitHub        = 'github'
+    Ahead         = ''
+    Behind        = ''
+    Diverged      = ''
+    Staged        = '+'
+    Unstaged      = '!'
+    Untracked     = '?'
+    Stashed       = '$'
+    SyncSeparator = ' '
+}
+
+function Get-PromptlyGitInfo {
+    git rev-parse --git-dir 2>$null | Out-Null
+    if ($LASTEXITCODE -ne 0) { return $null }
+
+    $branch = git symbolic-ref --short HEAD 2>$null
+    if (-not $branch) { $branch = git describe --tags --exact-match 2>$null }
+    if (-not $branch) { $branch = 'DETACHED' }
+
+    # Single git status call with branch info
+    $staged = 0; $unstaged = 0; $untracked = 0
+    foreach ($line in @(git status --porcelain -b 2>$null)) {
+        if ($line.Length -lt 2 -or $line.StartsWith('##')) { continue }
+        if ('AMDRCU'.Contains($line[0])) { $staged++ }
+        elseif ('MD'.Contains($line[1])) { $unstaged++ }
+        elseif ($line.StartsWith('??')) { $untracked++ }
+    }
+
+    $stashed = @(git stash list 2>$null).Count
+
+    # Detect GitHub by remote URL
+    $gitHost = $PromptlyIcons.Git
+    $remotes = "$(git config --get remote.origin.url 2>$null) $(git config --get remote.upstream.url 2>$null)"
+    if ($remotes -like '*github.com*') { $gitHost = $PromptlyIcons.GitHub }
+
+    # Ahead / behind
+    $sync = ''
+    $counts = git rev-list --left-right --count 'HEAD...@{u}' 2>$null
+    if ($LASTEXITCODE -eq 0 -and $counts) {
+        $ahead, $behind = -split $counts | ForEach-Object { [int]$_ }
+        $sep = $PromptlyIcons.SyncSeparator
+        if ($ahead -gt 0 -and $behind -gt 0) {
+            $sync = "$($PromptlyIcons.Diverged)$sep$ahead/$behind"
+        } elseif ($ahead -gt 0) {
+            $sync = "$($PromptlyIcons.Ahead)$sep$ahead"
+        } elseif ($behind -gt 0) {
+            $sync = "$($PromptlyIcons.Behind)$sep$behind"
+        }
+    }
+
+    [pscustomobject]@{
+        Host      = $gitHost
+        Branch    = $branch
+        Sync      = $sync
+        Staged    = if ($staged -gt 0) { "$($PromptlyIcons.Staged)$staged" } else { '' }
+        Unstaged  = if ($unstaged -gt 0) { "$($PromptlyIcons.Unstaged)$unstaged" } else { '' }
+        Untracked = if ($untracked -gt 0) { "$($PromptlyIcons.Untracked)$untracked" } else { '' }
+        Stashed   = if ($stashed -gt 0) { "$($PromptlyIcons.Stashed)$stashed" } else { '' }
+    }
+}
+
+function global:prompt {
+    $lastSuccess = $?
+    # git overwrites $LASTEXITCODE; put it back for the next command.
+    $lastExit = $global:LASTEXITCODE
+
+    $dir = $PWD.Path
+    if ($dir.StartsWith($HOME)) { $dir = '~' + $dir.Substring($HOME.Length) }
+    $git = Get-PromptlyGitInfo
+
+    $p = ''
+    $p += "`n"
+    $p += "$([char]27)[36m" + $dir + "$([char]27)[0m"
+    if ($git) { $p += "$([char]27)[34m" + ' ' + $git.Host + "$([char]27)[0m" }
+    if ($git) { $p += "$([char]27)[34m" + '(' + "$([char]27)[0m" }
+    if ($git) { $p += "$([char]27)[95m" + $git.Branch + "$([char]27)[0m" }
+    if ($git) { $p += "$([char]27)[34m" + ')' + "$([char]27)[0m" }
+    if ($git -and $git.Sync) { $p += "$([char]27)[36m" + ' ' + $git.Sync + "$([char]27)[0m" }
+    if ($git -and $git.Staged) { $p += "$([char]27)[32m" + ' ' + $git.Staged + "$([char]27)[0m" }
+    if ($git -and $git.Unstaged) { $p += "$([char]27)[33m" + ' ' + $git.Unstaged + "$([char]27)[0m" }
+    if ($git -and $git.Untracked) { $p += "$([char]27)[31m" + ' ' + $git.Untracked + "$([char]27)[0m" }
+    if ($git -and $git.Stashed) { $p += "$([char]27)[37m" + ' ' + $git.Stashed + "$([char]27)[0m" }
+    $p += "`n"
+    $p += "$([char]27)[34m" + '❯' + "$([char]27)[0m" + ' '
+
+    $global:LASTEXITCODE = $lastExit
+    $p
+}
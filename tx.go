@@ -0,0 +1,273 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Install transactions
+// ─────────────────────────────────────────────────────────────
+
+var errInterrupted = errors.New("interrupted")
+
+// Transaction groups the filesystem changes of one install or uninstall.
+// Every file is written to a temporary file and renamed into place, and each
+// step records how to undo itself so a failure part way through can put the
+// previous state back.
+//
+// A dry-run transaction touches nothing on disk and only records the changes
+// it would have made.
+type Transaction struct {
+	undo    []func() error
+	signals chan os.Signal
+	dryRun  bool
+	planned []FileChange
+}
+
+// FileChange is a change a dry-run transaction would have made to a file.
+type FileChange struct {
+	Path    string
+	Before  []byte
+	After   []byte
+	Existed bool
+	Removed bool
+}
+
+// runTransaction runs fn inside a Transaction. If fn fails, or the process
+// receives SIGINT/SIGTERM while it runs, every step fn already completed is
+// rolled back in reverse order.
+func runTransaction(fn func(tx *Transaction) error) error {
+	tx := &Transaction{signals: make(chan os.Signal, 1)}
+	signal.Notify(tx.signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(tx.signals)
+
+	err := fn(tx)
+	if err == nil && tx.interrupted() {
+		err = errInterrupted
+	}
+	if err != nil {
+		if rbErr := tx.rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// planTransaction runs fn in a dry-run Transaction and returns the changes
+// it would have made, in the order they were first made.
+func planTransaction(fn func(tx *Transaction) error) ([]FileChange, error) {
+	tx := &Transaction{dryRun: true}
+	if err := fn(tx); err != nil {
+		return nil, err
+	}
+
+	var changes []FileChange
+	for _, c := range tx.planned {
+		if c.Existed && !c.Removed && string(c.Before) == string(c.After) {
+			continue
+		}
+		if !c.Existed && c.Removed {
+			continue
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// DryRun reports whether tx only records changes.
+func (tx *Transaction) DryRun() bool {
+	return tx.dryRun
+}
+
+// plan records a dry-run change to path, merging it with earlier changes to
+// the same file so the diff is against what is on disk now.
+func (tx *Transaction) plan(path string, after []byte, removed bool) error {
+	for i := range tx.planned {
+		if tx.planned[i].Path == path {
+			tx.planned[i].After = after
+			tx.planned[i].Removed = removed
+			return nil
+		}
+	}
+
+	before, err := os.ReadFile(path)
+	existed := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	tx.planned = append(tx.planned, FileChange{
+		Path:    path,
+		Before:  before,
+		After:   after,
+		Existed: existed,
+		Removed: removed,
+	})
+	return nil
+}
+
+func (tx *Transaction) interrupted() bool {
+	select {
+	case <-tx.signals:
+		return true
+	default:
+		return false
+	}
+}
+
+// begin is called at the start of every step so an interrupt stops the
+// transaction before it changes anything else.
+func (tx *Transaction) begin() error {
+	if tx.interrupted() {
+		return errInterrupted
+	}
+	return nil
+}
+
+func (tx *Transaction) rollback() error {
+	var errs []error
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	tx.undo = nil
+	return errors.Join(errs...)
+}
+
+// MkdirAll creates path and any missing parents. Directories it created are
+// removed again on rollback if they are still empty.
+func (tx *Transaction) MkdirAll(path string, perm os.FileMode) error {
+	if err := tx.begin(); err != nil {
+		return err
+	}
+	if tx.dryRun {
+		return nil
+	}
+
+	var missing []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		missing = append(missing, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	if err := os.MkdirAll(path, perm); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, func() error {
+		for _, dir := range missing {
+			os.Remove(dir)
+		}
+		return nil
+	})
+	return nil
+}
+
+// WriteFile atomically replaces path with data. On rollback the previous
+// contents and mode are put back, or the file is removed if it is new.
+func (tx *Transaction) WriteFile(path string, data []byte, perm os.FileMode) error {
+	if err := tx.begin(); err != nil {
+		return err
+	}
+	if tx.dryRun {
+		return tx.plan(path, data, false)
+	}
+
+	restore, err := snapshotFile(path)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data, perm); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, restore)
+	return nil
+}
+
+// Remove deletes path. On rollback the file is recreated with its previous
+// contents and mode. Removing a missing file is not an error.
+func (tx *Transaction) Remove(path string) error {
+	if err := tx.begin(); err != nil {
+		return err
+	}
+	if tx.dryRun {
+		return tx.plan(path, nil, true)
+	}
+
+	restore, err := snapshotFile(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	tx.undo = append(tx.undo, restore)
+	return nil
+}
+
+// snapshotFile returns a function that puts path back the way it is now.
+func snapshotFile(path string) (func() error, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return func() error {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		return writeFileAtomic(path, content, info.Mode().Perm())
+	}, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers see either the old or the new contents, never a
+// partial file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".promptly-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransactionRollback(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, ".zshrc")
+	if err := os.WriteFile(existing, []byte("original\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	subdir := filepath.Join(dir, ".config", "promptly")
+	created := filepath.Join(subdir, "theme.zsh")
+	// A directory where a file should go makes the final rename fail after
+	// the temp file has been written.
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.MkdirAll(filepath.Join(blocked, "child"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runTransaction(func(tx *Transaction) error {
+		if err := tx.WriteFile(existing, []byte("changed\n"), 0644); err != nil {
+			return err
+		}
+		if err := tx.MkdirAll(subdir, 0755); err != nil {
+			return err
+		}
+		if err := tx.WriteFile(created, []byte("new\n"), 0644); err != nil {
+			return err
+		}
+		return tx.WriteFile(blocked, []byte("never\n"), 0644)
+	})
+	if err == nil {
+		t.Fatal("transaction succeeded writing over a directory")
+	}
+
+	if got := readString(t, existing); got != "original\n" {
+		t.Errorf("%s = %q after rollback, want the original", existing, got)
+	}
+	if info, err := os.Stat(existing); err != nil {
+		t.Fatal(err)
+	} else if perm := info.Mode().Perm(); perm != 0640 {
+		t.Errorf("rollback left %s with mode %v, want 0640", existing, perm)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("%s survived rollback: %v", created, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".config")); !os.IsNotExist(err) {
+		t.Errorf("directories created by the transaction survived rollback: %v", err)
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.Contains(d.Name(), ".promptly-") {
+			t.Errorf("temp file %s left behind", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransactionRollbackRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.fish")
+	if err := os.WriteFile(path, []byte("set -g x 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	errStop := errors.New("stop")
+	err := runTransaction(func(tx *Transaction) error {
+		if err := tx.Remove(path); err != nil {
+			return err
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("runTransaction = %v, want %v", err, errStop)
+	}
+	if got := readString(t, path); got != "set -g x 1\n" {
+		t.Errorf("rolled-back remove left %q", got)
+	}
+}
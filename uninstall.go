@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ─────────────────────────────────────────────────────────────
+// Uninstall
+// ─────────────────────────────────────────────────────────────
+
+// uninstall reverts everything the installers set up, as a single
+// transaction. Custom themes in ~/.config/promptly are left in place. It
+// returns the paths it changed or removed.
+func uninstall() ([]string, error) {
+	var changed []string
+	err := runTransaction(func(tx *Transaction) error {
+		var err error
+		changed, err = uninstallFiles(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+func uninstallFiles(tx *Transaction) ([]string, error) {
+	paths, err := resolvePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	rcFiles := paths.RCFiles()
+	installedFiles := []string{
+		paths.ZshTheme,
+		paths.BashTheme,
+		filepath.Join(paths.Config, "promptly.fish"),
+		filepath.Join(paths.Config, "promptly.ps1"),
+		filepath.Join(paths.Config, "promptly.nu"),
+		filepath.Join(paths.Config, "promptly.toml"),
+	}
+
+	// The nu starship block saves starship's init script on every start,
+	// so the script is promptly's to remove only while that block exists.
+	nuRC, err := readRCFile(paths.NuConfig)
+	if err != nil {
+		return nil, err
+	}
+	nuBlock, err := nuRC.Block()
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(nuBlock, nuStarshipAutoload) {
+		installedFiles = append(installedFiles, paths.NuStarshipInit)
+	}
+
+	var changed []string
+	for _, rcPath := range rcFiles {
+		removed, err := removeRCEntries(tx, rcPath)
+		if err != nil {
+			return changed, fmt.Errorf("failed to clean %s: %w", rcPath, err)
+		}
+		if removed {
+			changed = append(changed, rcPath)
+		}
+	}
+
+	for _, path := range installedFiles {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := tx.Remove(path); err != nil {
+			return changed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		changed = append(changed, path)
+	}
+
+	return changed, nil
+}
+
+// removeRCEntries deletes the promptly block and any entries left by older
+// versions of promptly from rcPath. It reports whether rcPath was modified.
+// A missing rc file is not an error.
+func removeRCEntries(tx *Transaction, rcPath string) (bool, error) {
+	rc, err := readRCFile(rcPath)
+	if err != nil {
+		return false, err
+	}
+	removed, err := rc.RemoveBlock()
+	if err != nil || !removed {
+		return false, err
+	}
+	if err := rc.Save(tx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func printUninstallResult(changed []string) {
+	if len(changed) == 0 {
+		fmt.Println("Nothing to uninstall: no promptly setup found.")
+		return
+	}
+	for _, path := range changed {
+		fmt.Printf("  - %s\n", path)
+	}
+	kept := "~/.config/promptly"
+	if paths, err := resolvePaths(); err == nil {
+		kept = displayPath(paths.Home, paths.Config)
+	}
+	color.Green("✓ Promptly uninstalled. Custom themes in %s were kept.", kept)
+	fmt.Println("Restart your terminal to apply the changes.")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useHome points promptly at a fresh home directory for the test.
+func useHome(t *testing.T) Paths {
+	t.Helper()
+	for _, name := range []string{"XDG_CONFIG_HOME", "XDG_DATA_HOME", "ZDOTDIR", "PROMPTLY_HOME"} {
+		t.Setenv(name, "")
+	}
+	homeOverride = t.TempDir()
+	t.Cleanup(func() { homeOverride = "" })
+	paths, err := resolvePaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func TestUninstallNuStarship(t *testing.T) {
+	paths := useHome(t)
+	theme := Theme{Name: "default", Contents: map[ShellTarget]string{ShellStarship: "format = '$all'\n"}}
+	if err := runTransaction(func(tx *Transaction) error { return installStarship(tx, theme, "nu") }); err != nil {
+		t.Fatal(err)
+	}
+	block := readString(t, paths.NuConfig)
+	if !strings.Contains(block, nuStarshipAutoload) {
+		t.Fatalf("config.nu doesn't save the init script:\n%s", block)
+	}
+
+	// nu writes the init script when it starts.
+	if err := os.MkdirAll(filepath.Dir(paths.NuStarshipInit), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(paths.NuStarshipInit, []byte("# starship\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := uninstall()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(paths.NuStarshipInit); !os.IsNotExist(err) {
+		t.Errorf("%s is left behind: %v", paths.NuStarshipInit, changed)
+	}
+}
+
+func TestUninstallKeepsOtherNuStarship(t *testing.T) {
+	paths := useHome(t)
+	if err := os.MkdirAll(filepath.Dir(paths.NuStarshipInit), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(paths.NuStarshipInit, []byte("# starship\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uninstall(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(paths.NuStarshipInit); err != nil {
+		t.Errorf("a starship init promptly didn't set up was removed: %v", err)
+	}
+}